@@ -0,0 +1,35 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"product-controller/repository"
+	"product-controller/service"
+)
+
+// verifyHistory - Komenda verify-history: sprawdza łańcuch historii bez uruchamiania serwera
+func verifyHistory(args []string) int {
+	flags := flag.NewFlagSet("verify-history", flag.ContinueOnError)
+	productID := flags.Uint("product", 0, "ID produktu (domyślnie cała tabela historii)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	productService := &service.ProductService{ProductRepo: repository.NewProductRepository()}
+	report, err := productService.VerifyHistoryChain(*productID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Błąd weryfikacji historii:", err)
+		return 2
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+
+	if !report.Valid {
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"os"
+	"product-controller/models"
 
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -22,3 +24,30 @@ func InitDB() {
 
 	log.Println("Połączono z bazą danych!")
 }
+
+// BlacklistMode - Reakcja na naruszenie blacklisty ze zmiennej BLACKLIST_MODE: reject (domyślnie) albo quarantine
+func BlacklistMode() string {
+	return os.Getenv("BLACKLIST_MODE")
+}
+
+// LowStockWebhookURL - Adres webhooka alertów niskiego stanu ze zmiennej LOW_STOCK_WEBHOOK_URL; pusty - alerty trafiają do logu
+func LowStockWebhookURL() string {
+	return os.Getenv("LOW_STOCK_WEBHOOK_URL")
+}
+
+// MigrateDB - Migracje wszystkich tabel
+func MigrateDB() error {
+	return DB.AutoMigrate(
+		&models.Product{},
+		&models.ProductHistory{},
+		&models.ProductHistoryHead{},
+		&models.BlacklistWord{},
+		&models.BlacklistHistory{},
+		&models.Category{},
+		&models.StockMovement{},
+		&models.StockReservation{},
+		&models.Warehouse{},
+		&models.StockLevel{},
+		&models.LowStockAlert{},
+	)
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"product-controller/models"
+	"product-controller/repository"
+	"product-controller/service"
+	"strconv"
+)
+
+type AlertController struct {
+	AlertService *service.AlertService
+}
+
+func NewAlertController(alertService *service.AlertService) *AlertController {
+	return &AlertController{
+		AlertService: alertService,
+	}
+}
+
+// GetLowStockAlerts - Alerty niskiego stanu od najnowszego (?status=open|acknowledged, ?product_id=, ?limit=)
+func (c *AlertController) GetLowStockAlerts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repository.LowStockAlertFilter{Status: q.Get("status")}
+
+	switch filter.Status {
+	case "", models.AlertOpen, models.AlertAcknowledged:
+	default:
+		http.Error(w, "parametr status musi mieć wartość open albo acknowledged", http.StatusBadRequest)
+		return
+	}
+
+	if param := q.Get("product_id"); param != "" {
+		productID, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+			return
+		}
+		filter.ProductID = uint(productID)
+	}
+
+	limit, err := parseLimit(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	alerts, err := c.AlertService.GetLowStockAlerts(filter, limit)
+	if err != nil {
+		http.Error(w, "Błąd pobierania alertów", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// AcknowledgeLowStockAlert - Potwierdzenie alertu niskiego stanu
+func (c *AlertController) AcknowledgeLowStockAlert(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID alertu", http.StatusBadRequest)
+		return
+	}
+
+	alert, err := c.AlertService.AcknowledgeLowStockAlert(r.Context(), uint(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAlertNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, service.ErrAlertAcknowledged):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Błąd potwierdzania alertu: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alert)
+}
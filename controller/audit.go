@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+	"product-controller/models"
+	"product-controller/service"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// AuditContext - Middleware zapisujący w kontekście autora, ID żądania, adres IP i powód zmiany
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get("X-Actor")
+		if actor == "" {
+			actor = r.Header.Get("X-API-Client")
+		}
+		if actor == "" {
+			actor = "anonymous"
+		}
+
+		// Adres bierzemy z połączenia, a nie z X-Forwarded-For / X-Real-IP, które klient może podrobić
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		info := models.AuditInfo{
+			Actor:     actor,
+			RequestID: middleware.GetReqID(r.Context()),
+			SourceIP:  ip,
+			Reason:    r.Header.Get("X-Change-Reason"),
+		}
+
+		next.ServeHTTP(w, r.WithContext(service.WithAuditInfo(r.Context(), info)))
+	})
+}
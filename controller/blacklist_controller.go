@@ -2,25 +2,30 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"github.com/go-chi/chi/v5"
+	"mime"
 	"net/http"
 	"product-controller/models"
 	"product-controller/repository"
+	"product-controller/service"
 	"strconv"
 )
 
 type BlacklistController struct {
-	BlacklistRepo *repository.BlacklistRepository
+	BlacklistService *service.BlacklistService
+	ScanService      *service.BlacklistScanService
 }
 
-func NewBlacklistController(blacklistRepo *repository.BlacklistRepository) *BlacklistController {
+func NewBlacklistController(blacklistService *service.BlacklistService, scanService *service.BlacklistScanService) *BlacklistController {
 	return &BlacklistController{
-		BlacklistRepo: blacklistRepo,
+		BlacklistService: blacklistService,
+		ScanService:      scanService,
 	}
 }
 
 func (c *BlacklistController) GetAllBlacklistWords(w http.ResponseWriter, r *http.Request) {
-	words, err := c.BlacklistRepo.GetAllBlacklistWords()
+	words, err := c.BlacklistService.GetAllBlacklistWords()
 	if err != nil {
 		http.Error(w, "Błąd pobierania blacklisty", http.StatusInternalServerError)
 		return
@@ -38,17 +43,33 @@ func (c *BlacklistController) AddBlacklistWord(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	if word.Word == "" {
-		http.Error(w, "Pole 'Word' jest wymagane", http.StatusBadRequest)
+	if err = service.ValidateBlacklistWord(&word); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = c.BlacklistRepo.AddBlacklistWord(&word)
+	// Akcja skanowania istniejących produktów po dodaniu słowa (?action=report|flag|unpublish)
+	action, err := service.ParseScanAction(r.URL.Query().Get("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = c.BlacklistService.AddBlacklistWord(r.Context(), &word)
+	if errors.Is(err, service.ErrBlacklistWordExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Błąd dodawania słowa do blacklisty", http.StatusInternalServerError)
 		return
 	}
 
+	job, err := c.ScanService.StartScan(r.Context(), action, "word:"+strconv.Itoa(int(word.ID)))
+	if err == nil {
+		w.Header().Set("X-Scan-Job", "/blacklist/scan/"+job.ID)
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(word)
 }
@@ -61,7 +82,11 @@ func (c *BlacklistController) DeleteBlacklistWord(w http.ResponseWriter, r *http
 		return
 	}
 
-	err = c.BlacklistRepo.DeleteBlacklistWord(uint(id))
+	err = c.BlacklistService.DeleteBlacklistWord(r.Context(), uint(id))
+	if errors.Is(err, service.ErrBlacklistWordNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Błąd usuwania słowa z blacklisty", http.StatusInternalServerError)
 		return
@@ -69,3 +94,168 @@ func (c *BlacklistController) DeleteBlacklistWord(w http.ResponseWriter, r *http
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// RestoreBlacklistWord - Przywrócenie usuniętego słowa
+func (c *BlacklistController) RestoreBlacklistWord(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID", http.StatusBadRequest)
+		return
+	}
+
+	word, err := c.BlacklistService.RestoreBlacklistWord(r.Context(), uint(id))
+	if errors.Is(err, service.ErrBlacklistWordNotDeleted) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Błąd przywracania słowa na blacklistę", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(word)
+}
+
+// GetBlacklistHistory - Historia zmian blacklisty (?word_id=, ?action=, ?actor=, ?limit=)
+func (c *BlacklistController) GetBlacklistHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := repository.BlacklistHistoryFilter{
+		Action: q.Get("action"),
+		Actor:  q.Get("actor"),
+	}
+
+	if param := q.Get("word_id"); param != "" {
+		wordID, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			http.Error(w, "Nieprawidłowe ID słowa", http.StatusBadRequest)
+			return
+		}
+		filter.WordID = uint(wordID)
+	}
+
+	limit, err := parseLimit(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history, err := c.BlacklistService.GetBlacklistHistory(filter, limit)
+	if err != nil {
+		http.Error(w, "Błąd pobierania historii blacklisty", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// Maksymalny rozmiar importowanego pliku
+const maxBlacklistImportSize = 10 << 20
+
+// Formaty blacklisty rozpoznawane po nagłówku Content-Type
+var blacklistContentTypes = map[string]string{
+	"text/plain":       service.BlacklistFormatText,
+	"text/csv":         service.BlacklistFormatCSV,
+	"application/json": service.BlacklistFormatJSON,
+}
+
+// ImportBlacklistWords - Import wielu słów z pliku txt, csv albo json (?format= albo Content-Type)
+func (c *BlacklistController) ImportBlacklistWords(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = blacklistContentTypes[mediaType]
+	}
+	format, err := service.ParseBlacklistFormat(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	action, err := service.ParseScanAction(r.URL.Query().Get("action"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := service.ReadBlacklistImport(format, http.MaxBytesReader(w, r.Body, maxBlacklistImportSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := c.BlacklistService.ImportBlacklistWords(r.Context(), rows)
+	if err != nil {
+		http.Error(w, "Błąd importu blacklisty: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if report.Created > 0 {
+		job, err := c.ScanService.StartScan(r.Context(), action, "import")
+		if err == nil {
+			w.Header().Set("X-Scan-Job", "/blacklist/scan/"+job.ID)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// ExportBlacklistWords - Eksport blacklisty w formacie txt, csv albo json (domyślnie)
+func (c *BlacklistController) ExportBlacklistWords(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.BlacklistFormatJSON
+	}
+	format, err := service.ParseBlacklistFormat(format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	words, err := c.BlacklistService.GetAllBlacklistWords()
+	if err != nil {
+		http.Error(w, "Błąd pobierania blacklisty", http.StatusInternalServerError)
+		return
+	}
+
+	for contentType, f := range blacklistContentTypes {
+		if f == format {
+			w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+		}
+	}
+	w.Header().Set("Content-Disposition", `attachment; filename="blacklist.`+format+`"`)
+	service.WriteBlacklistExport(format, w, words)
+}
+
+// StartScan - Skanowanie istniejących produktów względem aktualnej blacklisty (?action=report|flag|unpublish).
+// Stan zadania (Location, X-Scan-Job) jest trzymany w pamięci tej instancji i tylko dla 100 ostatnich zadań:
+// po restarcie albo przy zapytaniu do innej instancji GET /blacklist/scan/{id} zwróci 404, mimo że zmiany
+// statusów produktów zostały zapisane
+func (c *BlacklistController) StartScan(w http.ResponseWriter, r *http.Request) {
+	job, err := c.ScanService.StartScan(r.Context(), r.URL.Query().Get("action"), "manual")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/blacklist/scan/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetScanJob - Postęp i wynik zadania skanowania; 404 także dla zadań utraconych po restarcie lub usuniętych
+// z pamięci (zob. StartScan)
+func (c *BlacklistController) GetScanJob(w http.ResponseWriter, r *http.Request) {
+	job, err := c.ScanService.GetScanJob(chi.URLParam(r, "id"))
+	if errors.Is(err, service.ErrScanJobNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"product-controller/models"
+	"product-controller/service"
+	"strconv"
+	"strings"
+)
+
+type CategoryController struct {
+	CategoryService *service.CategoryService
+}
+
+func NewCategoryController(categoryService *service.CategoryService) *CategoryController {
+	return &CategoryController{
+		CategoryService: categoryService,
+	}
+}
+
+func (c *CategoryController) GetAllCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := c.CategoryService.GetAllCategories()
+	if err != nil {
+		http.Error(w, "Błąd pobierania kategorii", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categories)
+}
+
+func (c *CategoryController) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID kategorii", http.StatusBadRequest)
+		return
+	}
+
+	category, err := c.CategoryService.GetCategoryByID(uint(id))
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(category)
+}
+
+func (c *CategoryController) AddCategory(w http.ResponseWriter, r *http.Request) {
+	var category models.Category
+	err := json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	err = c.CategoryService.AddCategory(&category)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
+}
+
+func (c *CategoryController) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID kategorii", http.StatusBadRequest)
+		return
+	}
+
+	var category models.Category
+	err = json.NewDecoder(r.Body).Decode(&category)
+	if err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	err = c.CategoryService.UpdateCategory(uint(id), &category)
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(category)
+}
+
+func (c *CategoryController) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID kategorii", http.StatusBadRequest)
+		return
+	}
+
+	err = c.CategoryService.DeleteCategory(uint(id))
+	if err != nil {
+		writeCategoryError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCategoryError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "nie istnieje"):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrCategoryExists), errors.Is(err, service.ErrCategoryInUse):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
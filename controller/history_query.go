@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"product-controller/repository"
+	"product-controller/service"
+	"strconv"
+)
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+// parseHistoryQuery - Filtry, kolejność i kursor historii zmian z parametrów zapytania
+func parseHistoryQuery(r *http.Request, productID uint) (repository.HistoryQuery, error) {
+	q := r.URL.Query()
+	query := repository.HistoryQuery{
+		Filter: repository.HistoryFilter{
+			ProductID: productID,
+			Field:     q.Get("field"),
+			Actor:     q.Get("actor"),
+		},
+		Limit: defaultHistoryLimit,
+	}
+
+	var err error
+	if query.Filter.From, err = parseTimeParam(q, "from", false); err != nil {
+		return query, err
+	}
+	if query.Filter.To, err = parseTimeParam(q, "to", true); err != nil {
+		return query, err
+	}
+
+	if param := q.Get("limit"); param != "" {
+		query.Limit, err = strconv.Atoi(param)
+		if err != nil || query.Limit < 1 || query.Limit > maxHistoryLimit {
+			return query, fmt.Errorf("parametr limit musi być liczbą od 1 do %d", maxHistoryLimit)
+		}
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		query.Keyset, err = decodeCursor(cursor)
+		if err != nil || query.Keyset.Column != "id" || query.Keyset.Backward {
+			return query, errors.New("nieprawidłowy kursor")
+		}
+		return query, nil
+	}
+
+	query.Keyset = &repository.Keyset{Column: "id"}
+	switch q.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Keyset.Desc = true
+	default:
+		return query, errors.New("parametr order musi mieć wartość asc albo desc")
+	}
+
+	return query, nil
+}
+
+func setHistoryPaginationHeaders(w http.ResponseWriter, r *http.Request, page *service.HistoryPage) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(page.Total, 10))
+
+	if page.Next != nil {
+		q := r.URL.Query()
+		q.Del("order")
+		q.Set("cursor", encodeCursor(page.Next))
+		next := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+	}
+}
@@ -1,15 +1,42 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
+	"io"
+	"log"
+	"mime"
 	"net/http"
 	"product-controller/models"
+	"product-controller/repository"
 	"product-controller/service"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// productRequest - Produkt z opcjonalnym powodem zmiany przekazanym w treści żądania
+type productRequest struct {
+	models.Product
+	Reason string
+}
+
+// context - Powód z treści żądania ma pierwszeństwo przed nagłówkiem X-Change-Reason
+func (p productRequest) context(r *http.Request) context.Context {
+	if p.Reason == "" {
+		return r.Context()
+	}
+	return service.WithReason(r.Context(), p.Reason)
+}
+
+// moderationRequest - Opcjonalne uzasadnienie decyzji moderatora
+type moderationRequest struct {
+	Reason string
+}
+
 type ProductController struct {
 	ProductService *service.ProductService
 }
@@ -21,31 +48,46 @@ func NewProductController(productService *service.ProductService) *ProductContro
 }
 
 func (c *ProductController) GetAllProducts(w http.ResponseWriter, r *http.Request) {
-	products, err := c.ProductService.ProductRepo.GetAllProducts()
+	query, err := parseProductQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	list, err := c.ProductService.ListProducts(query)
 	if err != nil {
 		http.Error(w, "Błąd pobierania produktów", http.StatusInternalServerError)
 		return
 	}
 
+	setPaginationHeaders(w, r, query, list)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	json.NewEncoder(w).Encode(list.Products)
 }
 
 func (c *ProductController) AddProduct(w http.ResponseWriter, r *http.Request) {
-	var product models.Product
-	err := json.NewDecoder(r.Body).Decode(&product)
+	var request productRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
 		return
 	}
+	product := request.Product
 
-	err = c.ProductService.AddProduct(&product)
+	err = c.ProductService.AddProduct(request.context(r), &product)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	// Produkt skierowany do moderacji został przyjęty, ale nie jest jeszcze widoczny
+	status := http.StatusCreated
+	if product.Status == models.StatusPending {
+		status = http.StatusAccepted
+	}
+
+	setETag(w, product.Version)
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(product)
 }
 
@@ -57,27 +99,71 @@ func (c *ProductController) UpdateProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var updatedProduct models.Product
-	err = json.NewDecoder(r.Body).Decode(&updatedProduct)
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var request productRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
 	if err != nil {
 		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
 		return
 	}
+	updatedProduct := request.Product
 
-	err = c.ProductService.UpdateProduct(uint(id), &updatedProduct)
+	err = c.ProductService.UpdateProduct(request.context(r), uint(id), &updatedProduct, version)
 	if err != nil {
-		if strings.Contains(err.Error(), "nie istnieje") {
-			http.Error(w, err.Error(), http.StatusNotFound)
-			return
-		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeProductError(w, err)
 		return
 	}
 
+	setETag(w, updatedProduct.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedProduct)
 }
 
+func (c *ProductController) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	var product *models.Product
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/merge-patch+json", "application/json":
+		product, err = c.ProductService.MergePatchProduct(r.Context(), uint(id), patch, version)
+	case "application/json-patch+json":
+		product, err = c.ProductService.JSONPatchProduct(r.Context(), uint(id), patch, version)
+	default:
+		http.Error(w, "Obsługiwane formaty: application/merge-patch+json, application/json-patch+json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
 func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	idParam := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
@@ -86,7 +172,16 @@ func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	err = c.ProductService.DeleteProduct(uint(id))
+	version, ok := requireIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	err = c.ProductService.DeleteProduct(r.Context(), uint(id), version)
+	if errors.Is(err, service.ErrProductNotFound) || errors.Is(err, repository.ErrVersionConflict) {
+		writeProductError(w, err)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Błąd usuwania produktu: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -95,6 +190,618 @@ func (c *ProductController) DeleteProduct(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusNoContent) // 204 No Content
 }
 
+func (c *ProductController) GetDeletedProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := c.ProductService.GetDeletedProducts()
+	if err != nil {
+		http.Error(w, "Błąd pobierania kosza", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(products)
+}
+
+func (c *ProductController) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	product, err := c.ProductService.RestoreProduct(r.Context(), uint(id))
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+func (c *ProductController) PurgeProduct(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	err = c.ProductService.PurgeProduct(uint(id))
+	if errors.Is(err, service.ErrProductNotInTrash) {
+		writeProductError(w, err)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Błąd usuwania produktu: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// revertRequest - Punkt w historii: ID wpisu albo chwila w czasie
+type revertRequest struct {
+	HistoryID uint
+	At        *time.Time
+	Reason    string
+}
+
+func (c *ProductController) RevertProduct(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	// If-Match jest tu opcjonalny
+	var version uint
+	if r.Header.Get("If-Match") != "" {
+		var ok bool
+		if version, ok = requireIfMatch(w, r); !ok {
+			return
+		}
+	}
+
+	var request revertRequest
+	err = json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if request.Reason != "" {
+		ctx = service.WithReason(ctx, request.Reason)
+	}
+
+	product, err := c.ProductService.RevertProduct(ctx, uint(id), request.HistoryID, request.At, version)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// Maksymalny rozmiar importowanego pliku z produktami
+const maxProductImportSize = 20 << 20
+
+// Formaty importu produktów rozpoznawane po nagłówku Content-Type
+var productImportContentTypes = map[string]string{
+	"text/csv":             service.ProductFormatCSV,
+	"application/x-ndjson": service.ProductFormatNDJSON,
+	"application/jsonl":    service.ProductFormatNDJSON,
+}
+
+// ImportProducts - Import produktów z CSV albo NDJSON (?format=, ?mode=best-effort|atomic|dry-run)
+func (c *ProductController) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format := q.Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		format = productImportContentTypes[mediaType]
+	}
+	if format != service.ProductFormatCSV && format != service.ProductFormatNDJSON {
+		http.Error(w, "Obsługiwane formaty: text/csv, application/x-ndjson", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	mode, err := service.ParseImportMode(q.Get("mode"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := service.ReadProductImport(format, http.MaxBytesReader(w, r.Body, maxProductImportSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := c.ProductService.ImportProducts(r.Context(), rows, mode)
+	if err != nil {
+		http.Error(w, "Błąd importu produktów: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Wycofany import atomic nie zmienił niczego w bazie
+	status := http.StatusOK
+	if mode == service.ImportAtomic && !report.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Typy MIME formatów eksportu
+var exportContentTypes = map[string]string{
+	service.ExportCSV:    "text/csv; charset=utf-8",
+	service.ExportNDJSON: "application/x-ndjson",
+	service.ExportXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ExportProducts - Strumieniowy eksport produktów (?format=csv|ndjson|xlsx albo nagłówek Accept) z filtrami listy
+func (c *ProductController) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	format, err := exportFormat(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+
+	filter, err := parseProductFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+format+`"`)
+
+	writer, err := service.NewProductExportWriter(format, w)
+	if err != nil {
+		log.Println("Błąd eksportu produktów:", err)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	err = c.ProductService.ExportProducts(filter, func(batch []models.Product) error {
+		for i := range batch {
+			if err := writer.Write(&batch[i]); err != nil {
+				return err
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = writer.Close()
+	}
+
+	// Nagłówki zostały już wysłane, więc błąd w trakcie strumienia możemy tylko zalogować
+	if err != nil {
+		log.Println("Błąd eksportu produktów:", err)
+	}
+}
+
+// exportFormat - Format z parametru format, a bez niego z nagłówka Accept; domyślnie CSV
+func exportFormat(r *http.Request) (string, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := exportContentTypes[format]; !ok {
+			return "", fmt.Errorf("nieznany format %q, dozwolone: csv, ndjson, xlsx", format)
+		}
+		return format, nil
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, _ := mime.ParseMediaType(strings.TrimSpace(accepted))
+		for format, contentType := range exportContentTypes {
+			if mediaType == strings.Split(contentType, ";")[0] {
+				return format, nil
+			}
+		}
+		if mediaType == "application/jsonl" {
+			return service.ExportNDJSON, nil
+		}
+	}
+	return service.ExportCSV, nil
+}
+
+// ApproveProduct - Moderacja: zatwierdzenie produktu oczekującego na decyzję
+func (c *ProductController) ApproveProduct(w http.ResponseWriter, r *http.Request) {
+	c.moderateProduct(w, r, c.ProductService.ApproveProduct)
+}
+
+// RejectProduct - Moderacja: odrzucenie produktu oczekującego na decyzję
+func (c *ProductController) RejectProduct(w http.ResponseWriter, r *http.Request) {
+	c.moderateProduct(w, r, c.ProductService.RejectProduct)
+}
+
+func (c *ProductController) moderateProduct(w http.ResponseWriter, r *http.Request,
+	decide func(ctx context.Context, id uint, version uint) (*models.Product, error)) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	// If-Match jest tu opcjonalny
+	var version uint
+	if r.Header.Get("If-Match") != "" {
+		var ok bool
+		if version, ok = requireIfMatch(w, r); !ok {
+			return
+		}
+	}
+
+	// Treść z uzasadnieniem decyzji jest opcjonalna
+	var request moderationRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil && err != io.EOF {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if request.Reason != "" {
+		ctx = service.WithReason(ctx, request.Reason)
+	}
+
+	product, err := decide(ctx, uint(id), version)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
+
+// stockMovementRequest - Ruch magazynowy w magazynie o kodzie Warehouse (pusty - domyślny) z opcjonalnym powodem
+type stockMovementRequest struct {
+	Type      string
+	Quantity  int
+	Warehouse string
+	Reference string
+	Reason    string
+}
+
+// PostStockMovement - Zaksięgowanie ruchu magazynowego produktu
+func (c *ProductController) PostStockMovement(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	// If-Match jest tu opcjonalny: równoległe ruchy nie kolidują, stan pilnuje warunek w bazie
+	var version uint
+	if r.Header.Get("If-Match") != "" {
+		var ok bool
+		if version, ok = requireIfMatch(w, r); !ok {
+			return
+		}
+	}
+
+	var request stockMovementRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if request.Reason != "" {
+		ctx = service.WithReason(ctx, request.Reason)
+	}
+
+	movement := models.StockMovement{
+		Type:      request.Type,
+		Quantity:  request.Quantity,
+		Reference: request.Reference,
+	}
+	product, err := c.ProductService.PostStockMovement(ctx, uint(id), request.Warehouse, &movement, version)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// GetStockMovements - Ruchy magazynowe produktu od najnowszego (?type=, ?limit=)
+func (c *ProductController) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, err := parseLimit(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movements, err := c.ProductService.GetStockMovements(uint(id), q.Get("type"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, "Błąd pobierania ruchów magazynowych", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
+
+// reservationRequest - Rezerwacja quantity sztuk w magazynie Warehouse (pusty - domyślny) na TTLSeconds sekund
+// (0 - domyślny czas ważności)
+type reservationRequest struct {
+	Quantity   int
+	TTLSeconds int
+	Warehouse  string
+	Reference  string
+	Reason     string
+}
+
+// ReserveStock - Czasowa rezerwacja części stanu produktu
+func (c *ProductController) ReserveStock(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	var request reservationRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if request.Reason != "" {
+		ctx = service.WithReason(ctx, request.Reason)
+	}
+
+	ttl := time.Duration(request.TTLSeconds) * time.Second
+	reservation, err := c.ProductService.ReserveStock(ctx, uint(id), request.Warehouse, request.Quantity, ttl, request.Reference)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/products/%d/reservations/%d", id, reservation.ID))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// GetReservations - Rezerwacje produktu od najnowszej (?status=, ?limit=)
+func (c *ProductController) GetReservations(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, err := parseLimit(q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reservations, err := c.ProductService.GetReservations(uint(id), q.Get("status"), limit)
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, "Błąd pobierania rezerwacji", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservations)
+}
+
+// ConfirmReservation - Zamiana rezerwacji na sprzedaż
+func (c *ProductController) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	id, reservationID, ok := reservationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, product, err := c.ProductService.ConfirmReservation(r.Context(), id, reservationID)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	setETag(w, product.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+// ReleaseReservation - Zwolnienie rezerwacji bez sprzedaży
+func (c *ProductController) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	id, reservationID, ok := reservationIDs(w, r)
+	if !ok {
+		return
+	}
+
+	reservation, err := c.ProductService.ReleaseReservation(r.Context(), id, reservationID)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservation)
+}
+
+func reservationIDs(w http.ResponseWriter, r *http.Request) (uint, uint, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	reservationID, err := strconv.ParseUint(chi.URLParam(r, "reservationId"), 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID rezerwacji", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return uint(id), uint(reservationID), true
+}
+
+// GetProductStock - Stany produktu w poszczególnych magazynach
+func (c *ProductController) GetProductStock(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := c.ProductService.GetProductStock(uint(id))
+	if err != nil {
+		if errors.Is(err, service.ErrProductNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+		} else {
+			http.Error(w, "Błąd pobierania stanów magazynowych", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+// transferRequest - Przesunięcie Quantity sztuk z magazynu From do magazynu To (kody magazynów)
+type transferRequest struct {
+	From      string
+	To        string
+	Quantity  int
+	Reference string
+	Reason    string
+}
+
+// TransferStock - Przesunięcie stanu produktu między magazynami
+func (c *ProductController) TransferStock(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	var request transferRequest
+	if err = json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	if request.Reason != "" {
+		ctx = service.WithReason(ctx, request.Reason)
+	}
+
+	levels, err := c.ProductService.TransferStock(ctx, uint(id), request.From, request.To, request.Quantity, request.Reference)
+	if err != nil {
+		writeProductError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+func (c *ProductController) GetProductAsOf(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	t, err := parseTimeParam(r.URL.Query(), "t", true)
+	if err != nil || t == nil {
+		http.Error(w, "Wymagany parametr t (RRRR-MM-DD lub RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	snapshot, err := c.ProductService.GetProductAsOf(uint(id), *t)
+	if err != nil {
+		writeSnapshotError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(snapshot)
+}
+
+func (c *ProductController) GetProductDiff(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+		return
+	}
+
+	from, err := parseTimeParam(r.URL.Query(), "from", false)
+	if err != nil || from == nil {
+		http.Error(w, "Wymagany parametr from (RRRR-MM-DD lub RFC3339)", http.StatusBadRequest)
+		return
+	}
+	to, err := parseTimeParam(r.URL.Query(), "to", true)
+	if err != nil || to == nil {
+		http.Error(w, "Wymagany parametr to (RRRR-MM-DD lub RFC3339)", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := c.ProductService.GetProductDiff(uint(id), *from, *to)
+	if err != nil {
+		writeSnapshotError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+func writeSnapshotError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrProductDidNotExist):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalidTimeRange):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Błąd odtwarzania historii produktu: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func (c *ProductController) GetProductHistory(w http.ResponseWriter, r *http.Request) {
 	idParam := chi.URLParam(r, "id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
@@ -103,14 +810,52 @@ func (c *ProductController) GetProductHistory(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	history, err := c.ProductService.GetProductHistory(uint(id))
+	c.writeHistory(w, r, uint(id))
+}
+
+// VerifyHistory - Weryfikacja łańcucha hashy historii jednego produktu (product_id) albo całej tabeli
+func (c *ProductController) VerifyHistory(w http.ResponseWriter, r *http.Request) {
+	var productID uint64
+	if param := r.URL.Query().Get("product_id"); param != "" {
+		var err error
+		productID, err = strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			http.Error(w, "Nieprawidłowe ID produktu", http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := c.ProductService.VerifyHistoryChain(uint(productID))
+	if err != nil {
+		http.Error(w, "Błąd weryfikacji historii: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// GetHistory - Historia zmian wszystkich produktów
+func (c *ProductController) GetHistory(w http.ResponseWriter, r *http.Request) {
+	c.writeHistory(w, r, 0)
+}
+
+func (c *ProductController) writeHistory(w http.ResponseWriter, r *http.Request, productID uint) {
+	query, err := parseHistoryQuery(r, productID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	page, err := c.ProductService.ListHistory(query)
 	if err != nil {
 		http.Error(w, "Błąd pobierania historii produktu", http.StatusInternalServerError)
 		return
 	}
 
+	setHistoryPaginationHeaders(w, r, page)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
+	json.NewEncoder(w).Encode(page.Entries)
 }
 
 func (c *ProductController) GetProductByID(w http.ResponseWriter, r *http.Request) {
@@ -130,7 +875,66 @@ func (c *ProductController) GetProductByID(w http.ResponseWriter, r *http.Reques
 		}
 		return
 	}
+	// Produkty w kwarantannie moderator widzi na liście (?status=pending,rejected), publicznie ich nie ma
+	if product.Quarantined() {
+		http.Error(w, "Produkt nie znaleziony", http.StatusNotFound)
+		return
+	}
 
+	setETag(w, product.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(product)
 }
+
+// requireIfMatch - Odczytanie oczekiwanej wersji z nagłówka If-Match; "*" oznacza dowolną wersję
+func requireIfMatch(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		http.Error(w, "Wymagany nagłówek If-Match z wartością ETag produktu", http.StatusPreconditionRequired)
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	version, err := strconv.ParseUint(tag, 10, 32)
+	if err != nil || version == 0 {
+		http.Error(w, repository.ErrVersionConflict.Error(), http.StatusPreconditionFailed)
+		return 0, false
+	}
+
+	return uint(version), true
+}
+
+func setETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", `"`+strconv.FormatUint(uint64(version), 10)+`"`)
+}
+
+func writeProductError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrProductNotInTrash),
+		errors.Is(err, service.ErrReservationNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, repository.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, service.ErrNotAwaitingModeration), errors.Is(err, service.ErrProductNotSellable),
+		errors.Is(err, service.ErrReservationNotActive), errors.Is(err, service.ErrStockInWarehouses),
+		errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrInsufficientWarehouseStock),
+		errors.Is(err, repository.ErrBelowReserved):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case isValidationError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, "Błąd serwera: "+err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// isValidationError - Błędy wynikające z treści żądania, zgłaszane klientowi jako 400
+func isValidationError(err error) bool {
+	var validation *service.ValidationError
+	var violation *service.BlacklistViolation
+	return errors.As(err, &validation) || errors.As(err, &violation) ||
+		errors.Is(err, repository.ErrNegativeStock) || errors.Is(err, service.ErrWarehouseNotFound) ||
+		errors.Is(err, service.ErrProductDidNotExist)
+}
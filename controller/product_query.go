@@ -0,0 +1,316 @@
+package controller
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"product-controller/models"
+	"product-controller/repository"
+	"product-controller/service"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+)
+
+// parseProductQuery - Odczytanie filtrów, sortowania i stronicowania z parametrów zapytania
+func parseProductQuery(r *http.Request) (repository.ProductQuery, error) {
+	q := r.URL.Query()
+	var query repository.ProductQuery
+
+	filter, err := parseProductFilter(q)
+	if err != nil {
+		return query, err
+	}
+	query.Filter = filter
+
+	query.Limit, err = parseLimit(q)
+	if err != nil {
+		return query, err
+	}
+
+	if cursor := q.Get("cursor"); cursor != "" {
+		query.Keyset, err = decodeCursor(cursor)
+		return query, err
+	}
+
+	query.Sort, err = parseSort(q.Get("sort"))
+	if err != nil {
+		return query, err
+	}
+
+	_, hasOffset := q["offset"]
+	_, hasPage := q["page"]
+	if hasOffset || hasPage {
+		query.Offset, err = parseOffset(q, query.Limit)
+		return query, err
+	}
+
+	// Sortowanie po jednej kolumnie ID/UpdatedAt pozwala na stronicowanie kursorem
+	if keyset := keysetFromSort(query.Sort); keyset != nil {
+		query.Keyset = keyset
+	}
+
+	return query, nil
+}
+
+func parseProductFilter(q url.Values) (repository.ProductFilter, error) {
+	var f repository.ProductFilter
+	var err error
+
+	f.Category = q.Get("category")
+	f.NamePrefix = q.Get("name_prefix")
+	f.NameContains = q.Get("name")
+
+	if f.MinPrice, err = parseFloatParam(q, "min_price"); err != nil {
+		return f, err
+	}
+	if f.MaxPrice, err = parseFloatParam(q, "max_price"); err != nil {
+		return f, err
+	}
+	if f.MinQuantity, err = parseIntParam(q, "min_quantity"); err != nil {
+		return f, err
+	}
+	if f.MaxQuantity, err = parseIntParam(q, "max_quantity"); err != nil {
+		return f, err
+	}
+	if f.CreatedFrom, err = parseTimeParam(q, "created_from", false); err != nil {
+		return f, err
+	}
+	if f.CreatedTo, err = parseTimeParam(q, "created_to", true); err != nil {
+		return f, err
+	}
+	if f.UpdatedFrom, err = parseTimeParam(q, "updated_from", false); err != nil {
+		return f, err
+	}
+	if f.UpdatedTo, err = parseTimeParam(q, "updated_to", true); err != nil {
+		return f, err
+	}
+
+	if f.Statuses, err = parseStatuses(q.Get("status")); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// Statusy produktów i te z nich, które lista pokazuje domyślnie
+var (
+	productStatuses        = []string{models.StatusActive, models.StatusFlagged, models.StatusUnpublished, models.StatusPending, models.StatusRejected}
+	defaultProductStatuses = []string{models.StatusActive, models.StatusFlagged}
+)
+
+// parseStatuses - Lista statusów rozdzielonych przecinkami; "all" - wszystkie, brak - domyślnie widoczne
+func parseStatuses(param string) ([]string, error) {
+	if param == "" {
+		return defaultProductStatuses, nil
+	}
+	if param == "all" {
+		return nil, nil
+	}
+
+	var statuses []string
+	for _, status := range strings.Split(param, ",") {
+		status = strings.TrimSpace(status)
+		known := false
+		for _, s := range productStatuses {
+			known = known || s == status
+		}
+		if !known {
+			return nil, fmt.Errorf("nieznany status produktu: %s, dozwolone: %s", status, strings.Join(productStatuses, ", "))
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+func parseSort(param string) ([]repository.SortField, error) {
+	var sort []repository.SortField
+	hasID := false
+
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+		column, ok := repository.ProductSortColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("nie można sortować po polu: %s", name)
+		}
+		if column == "id" {
+			hasID = true
+		}
+		sort = append(sort, repository.SortField{Column: column, Desc: desc})
+	}
+
+	// ID jako ostatnie kryterium zapewnia stałą kolejność wyników
+	if !hasID {
+		sort = append(sort, repository.SortField{Column: "id"})
+	}
+
+	return sort, nil
+}
+
+func keysetFromSort(sort []repository.SortField) *repository.Keyset {
+	switch {
+	case len(sort) == 1 && sort[0].Column == "id":
+		return &repository.Keyset{Column: "id", Desc: sort[0].Desc}
+	case len(sort) == 2 && sort[0].Column == "updated_at" && sort[1].Column == "id":
+		return &repository.Keyset{Column: "updated_at", Desc: sort[0].Desc}
+	}
+	return nil
+}
+
+func parseLimit(q url.Values) (int, error) {
+	param := q.Get("limit")
+	if param == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(param)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, fmt.Errorf("parametr limit musi być liczbą od 1 do %d", maxPageLimit)
+	}
+	return limit, nil
+}
+
+func parseOffset(q url.Values, limit int) (int, error) {
+	if param := q.Get("page"); param != "" {
+		page, err := strconv.Atoi(param)
+		if err != nil || page < 1 {
+			return 0, errors.New("parametr page musi być liczbą większą od 0")
+		}
+		return (page - 1) * limit, nil
+	}
+
+	offset, err := strconv.Atoi(q.Get("offset"))
+	if err != nil || offset < 0 {
+		return 0, errors.New("parametr offset musi być liczbą nieujemną")
+	}
+	return offset, nil
+}
+
+func parseFloatParam(q url.Values, name string) (*float64, error) {
+	param := q.Get(name)
+	if param == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parametr %s musi być liczbą", name)
+	}
+	return &value, nil
+}
+
+func parseIntParam(q url.Values, name string) (*int, error) {
+	param := q.Get(name)
+	if param == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.Atoi(param)
+	if err != nil {
+		return nil, fmt.Errorf("parametr %s musi być liczbą całkowitą", name)
+	}
+	return &value, nil
+}
+
+// parseTimeParam - Akceptuje RFC3339 albo samą datę; data jako górna granica obejmuje cały dzień
+func parseTimeParam(q url.Values, name string, endOfDay bool) (*time.Time, error) {
+	param := q.Get(name)
+	if param == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, param); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", param, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("parametr %s musi być datą (RRRR-MM-DD) lub czasem RFC3339", name)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return &t, nil
+}
+
+func encodeCursor(k *repository.Keyset) string {
+	data, _ := json.Marshal(k)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string) (*repository.Keyset, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("nieprawidłowy kursor")
+	}
+
+	var k repository.Keyset
+	if err = json.Unmarshal(data, &k); err != nil || (k.Column != "id" && k.Column != "updated_at") {
+		return nil, errors.New("nieprawidłowy kursor")
+	}
+	return &k, nil
+}
+
+// setPaginationHeaders - Łączna liczba wyników w X-Total-Count i linki next/prev w nagłówku Link
+func setPaginationHeaders(w http.ResponseWriter, r *http.Request, query repository.ProductQuery, list *service.ProductList) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(list.Total, 10))
+
+	var links []string
+	if list.HasNext {
+		if next := pageURL(r, query, list, false); next != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="next"`, next))
+		}
+	}
+	if list.HasPrev {
+		if prev := pageURL(r, query, list, true); prev != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, prev))
+		}
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+func pageURL(r *http.Request, query repository.ProductQuery, list *service.ProductList, prev bool) string {
+	q := r.URL.Query()
+	q.Del("page")
+	q.Del("offset")
+	q.Del("cursor")
+
+	if query.Keyset != nil {
+		k := list.Next
+		if prev {
+			k = list.Prev
+		}
+		if k == nil {
+			return ""
+		}
+		q.Set("cursor", encodeCursor(k))
+	} else {
+		offset := query.Offset + query.Limit
+		if prev {
+			offset = query.Offset - query.Limit
+			if offset < 0 {
+				offset = 0
+			}
+		}
+		q.Set("offset", strconv.Itoa(offset))
+	}
+
+	u := url.URL{Path: r.URL.Path, RawQuery: q.Encode()}
+	return u.String()
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"net/http"
+	"product-controller/models"
+	"product-controller/service"
+	"strconv"
+)
+
+type WarehouseController struct {
+	WarehouseService *service.WarehouseService
+}
+
+func NewWarehouseController(warehouseService *service.WarehouseService) *WarehouseController {
+	return &WarehouseController{
+		WarehouseService: warehouseService,
+	}
+}
+
+func (c *WarehouseController) GetAllWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, err := c.WarehouseService.GetAllWarehouses()
+	if err != nil {
+		http.Error(w, "Błąd pobierania magazynów", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(warehouses)
+}
+
+func (c *WarehouseController) AddWarehouse(w http.ResponseWriter, r *http.Request) {
+	var warehouse models.Warehouse
+	err := json.NewDecoder(r.Body).Decode(&warehouse)
+	if err != nil {
+		http.Error(w, "Niepoprawne dane wejściowe", http.StatusBadRequest)
+		return
+	}
+
+	err = c.WarehouseService.AddWarehouse(&warehouse)
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(warehouse)
+}
+
+// GetWarehouseStock - Stany wszystkich produktów w magazynie
+func (c *WarehouseController) GetWarehouseStock(w http.ResponseWriter, r *http.Request) {
+	idParam := chi.URLParam(r, "id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		http.Error(w, "Nieprawidłowe ID magazynu", http.StatusBadRequest)
+		return
+	}
+
+	levels, err := c.WarehouseService.GetWarehouseStock(uint(id))
+	if err != nil {
+		writeWarehouseError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(levels)
+}
+
+func writeWarehouseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrWarehouseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrWarehouseExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -1,11 +1,12 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
 	"product-controller/config"
 	"product-controller/controller"
-	"product-controller/models"
 	"product-controller/repository"
 	"product-controller/service"
 
@@ -16,12 +17,13 @@ import (
 func main() {
 	config.InitDB()
 
+	// Uruchomienie bez serwera: product-controller verify-history [-product ID]
+	if len(os.Args) > 1 && os.Args[1] == "verify-history" {
+		os.Exit(verifyHistory(os.Args[2:]))
+	}
+
 	// Migracje
-	err := config.DB.AutoMigrate(
-		&models.Product{},
-		&models.ProductHistory{},
-		&models.BlacklistWord{},
-	)
+	err := config.MigrateDB()
 	if err != nil {
 		log.Fatal("Błąd migracji:", err)
 	}
@@ -29,28 +31,107 @@ func main() {
 	// Inicjalizacja warstw
 	productRepo := repository.NewProductRepository()
 	blacklistRepo := repository.NewBlacklistRepository()
+	categoryRepo := repository.NewCategoryRepository()
+	warehouseRepo := repository.NewWarehouseRepository()
+
+	if err = categoryRepo.EnsureDefaultCategories(); err != nil {
+		log.Fatal("Błąd zakładania domyślnych kategorii:", err)
+	}
+	if err = warehouseRepo.EnsureDefaultWarehouse(); err != nil {
+		log.Fatal("Błąd zakładania domyślnego magazynu:", err)
+	}
+	if err = productRepo.EnsureProductHistoryHeads(); err != nil {
+		log.Fatal("Błąd zapisu końców łańcucha historii:", err)
+	}
+
+	blacklistService := service.NewBlacklistService(blacklistRepo)
+	productService := service.NewProductService(productRepo, blacklistService, categoryRepo, warehouseRepo)
+	if productService.BlacklistMode, err = service.ParseBlacklistMode(config.BlacklistMode()); err != nil {
+		log.Fatal("Błąd konfiguracji blacklisty:", err)
+	}
+	categoryService := service.NewCategoryService(categoryRepo)
+	warehouseService := service.NewWarehouseService(warehouseRepo)
+
+	// Alerty niskiego stanu: webhook, jeśli skonfigurowano adres, w przeciwnym razie log
+	var notifier service.LowStockNotifier = service.NewLogNotifier(nil)
+	if url := config.LowStockWebhookURL(); url != "" {
+		notifier = service.NewWebhookNotifier(url)
+	}
+	alertService := service.NewAlertService(productRepo, notifier)
+	scanService := service.NewBlacklistScanService(productService)
+
+	// Zwalnianie wygasłych rezerwacji w tle
+	go productService.RunReservationSweeper(context.Background(), service.ReservationSweepInterval)
+	go alertService.RunAlertDispatcher(context.Background(), service.AlertDispatchInterval)
 
-	productService := service.NewProductService(productRepo, blacklistRepo)
 	productController := controller.NewProductController(productService)
-	blacklistController := controller.NewBlacklistController(blacklistRepo)
+	blacklistController := controller.NewBlacklistController(blacklistService, scanService)
+	categoryController := controller.NewCategoryController(categoryService)
+	warehouseController := controller.NewWarehouseController(warehouseService)
+	alertController := controller.NewAlertController(alertService)
 
 	// Router
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Logger)
+	r.Use(controller.AuditContext)
 
 	// Endpointy
 	r.Get("/products", productController.GetAllProducts)
+	r.Get("/products/trash", productController.GetDeletedProducts)
+	r.Post("/products/import", productController.ImportProducts)
+	r.Get("/products/export", productController.ExportProducts)
+	r.Post("/products/{id}/restore", productController.RestoreProduct)
+	r.Delete("/products/{id}/purge", productController.PurgeProduct)
 	r.Post("/products", productController.AddProduct)
 	r.Delete("/products/{id}", productController.DeleteProduct)
 	r.Put("/products/{id}", productController.UpdateProduct)
+	r.Patch("/products/{id}", productController.PatchProduct)
 	r.Get("/products/{id}", productController.GetProductByID)
 
 	// Endpointy dla blacklisty
 	r.Get("/blacklist", blacklistController.GetAllBlacklistWords)
 	r.Post("/blacklist", blacklistController.AddBlacklistWord)
 	r.Delete("/blacklist/{id}", blacklistController.DeleteBlacklistWord)
+	r.Post("/blacklist/{id}/restore", blacklistController.RestoreBlacklistWord)
+	r.Get("/blacklist/history", blacklistController.GetBlacklistHistory)
+	r.Post("/blacklist/import", blacklistController.ImportBlacklistWords)
+	r.Get("/blacklist/export", blacklistController.ExportBlacklistWords)
+	r.Post("/blacklist/scan", blacklistController.StartScan)
+	r.Get("/blacklist/scan/{id}", blacklistController.GetScanJob)
 
 	r.Get("/products/{id}/history", productController.GetProductHistory)
+	r.Post("/products/{id}/revert", productController.RevertProduct)
+	r.Post("/products/{id}/approve", productController.ApproveProduct)
+	r.Post("/products/{id}/reject", productController.RejectProduct)
+	r.Get("/products/{id}/as-of", productController.GetProductAsOf)
+	r.Get("/products/{id}/diff", productController.GetProductDiff)
+	r.Post("/products/{id}/stock-movements", productController.PostStockMovement)
+	r.Get("/products/{id}/stock-movements", productController.GetStockMovements)
+	r.Post("/products/{id}/reservations", productController.ReserveStock)
+	r.Get("/products/{id}/reservations", productController.GetReservations)
+	r.Post("/products/{id}/reservations/{reservationId}/confirm", productController.ConfirmReservation)
+	r.Post("/products/{id}/reservations/{reservationId}/release", productController.ReleaseReservation)
+	r.Get("/products/{id}/stock", productController.GetProductStock)
+	r.Post("/products/{id}/transfers", productController.TransferStock)
+	r.Get("/history", productController.GetHistory)
+	r.Get("/history/verify", productController.VerifyHistory)
+
+	// Endpointy dla kategorii
+	r.Get("/categories", categoryController.GetAllCategories)
+	r.Post("/categories", categoryController.AddCategory)
+	r.Get("/categories/{id}", categoryController.GetCategoryByID)
+	r.Put("/categories/{id}", categoryController.UpdateCategory)
+	r.Delete("/categories/{id}", categoryController.DeleteCategory)
+
+	// Endpointy dla magazynów
+	r.Get("/warehouses", warehouseController.GetAllWarehouses)
+	r.Post("/warehouses", warehouseController.AddWarehouse)
+	r.Get("/warehouses/{id}/stock", warehouseController.GetWarehouseStock)
+
+	// Endpointy dla alertów
+	r.Get("/alerts/low-stock", alertController.GetLowStockAlerts)
+	r.Post("/alerts/low-stock/{id}/acknowledge", alertController.AcknowledgeLowStockAlert)
 
 	log.Println("Serwer nasłuchuje na porcie :8080")
 	http.ListenAndServe(":8080", r)
@@ -0,0 +1,9 @@
+package models
+
+// AuditInfo - Kto, skąd i dlaczego wprowadził zmianę
+type AuditInfo struct {
+	Actor     string `gorm:"size:100"`
+	RequestID string `gorm:"size:100"`
+	SourceIP  string `gorm:"size:64"`
+	Reason    string `gorm:"size:500"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Rodzaje zmian blacklisty
+const (
+	BlacklistAdded    = "added"
+	BlacklistDeleted  = "deleted"
+	BlacklistRestored = "restored"
+)
+
+// BlacklistHistory - Zmiana blacklisty wraz ze stanem reguły w chwili zmiany
+type BlacklistHistory struct {
+	ID         uint      `gorm:"primaryKey"`
+	WordID     uint      `gorm:"not null;index"`
+	Action     string    `gorm:"size:20;not null"` // added, deleted, restored
+	Word       string    `gorm:"size:255;not null"`
+	MatchMode  string    `gorm:"size:20"`
+	Fields     string    `gorm:"size:20"`
+	Categories string    `gorm:"size:500"`
+	ChangedAt  time.Time `gorm:"autoCreateTime;index"`
+	AuditInfo  `gorm:"embedded"`
+}
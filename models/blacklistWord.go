@@ -1,6 +1,27 @@
 package models
 
+import "gorm.io/gorm"
+
+// Tryby dopasowania słowa z blacklisty
+const (
+	MatchSubstring  = "substring"  // fragment nazwy, bez rozróżniania wielkości liter
+	MatchWord       = "word"       // całe słowo (granice: znaki niealfanumeryczne, camelCase, litera/cyfra)
+	MatchRegex      = "regex"      // wyrażenie regularne (RE2), bez rozróżniania wielkości liter
+	MatchNormalized = "normalized" // fragment po normalizacji: leetspeak, powtórzenia, polskie znaki
+)
+
+// Pola produktu, których dotyczy słowo z blacklisty
+const (
+	FieldsAll         = "all"
+	FieldsName        = "name"
+	FieldsDescription = "description"
+)
+
 type BlacklistWord struct {
-	ID   uint   `gorm:"primaryKey"`
-	Word string `gorm:"size:255;not null;unique"`
+	ID         uint           `gorm:"primaryKey"`
+	Word       string         `gorm:"size:255;not null;unique"`
+	MatchMode  string         `gorm:"size:20;not null;default:substring"`
+	Fields     string         `gorm:"size:20;not null;default:all"`
+	Categories string         `gorm:"size:500"` // nazwy kategorii rozdzielone przecinkami; puste - wszystkie kategorie
+	DeletedAt  gorm.DeletedAt `gorm:"index"`
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+type Category struct {
+	ID        uint    `gorm:"primaryKey"`
+	Name      string  `gorm:"size:50;not null;unique"`
+	MinPrice  float64 `gorm:"not null"`
+	MaxPrice  float64 `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Statusy alertów niskiego stanu
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+)
+
+// LowStockAlert - Alert o zejściu stanu produktu do progu zamówienia, wraz ze stanem w chwili alertu
+type LowStockAlert struct {
+	ID              uint       `gorm:"primaryKey"`
+	ProductID       uint       `gorm:"not null;index"`
+	ProductName     string     `gorm:"size:255;not null"`
+	Quantity        int        `gorm:"not null"`
+	ReorderPoint    int        `gorm:"not null"`
+	ReorderQuantity int        `gorm:"not null"`
+	Status          string     `gorm:"size:20;not null;default:open;index"`
+	CreatedAt       time.Time  `gorm:"index"`
+	NotifiedAt      *time.Time `gorm:"index"` // nil - alert czeka na wysłanie powiadomienia
+	NotifyAttempts  int        `gorm:"not null;default:0"`
+	NotifyError     string     `gorm:"size:500"`
+	AcknowledgedAt  *time.Time
+	AcknowledgedBy  string `gorm:"size:100"`
+}
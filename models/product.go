@@ -1,18 +1,64 @@
 package models
 
 import (
+	"encoding/json"
 	"gorm.io/gorm"
 	"time"
 )
 
+// Statusy publikacji produktu
+const (
+	StatusActive      = "active"      // widoczny na liście
+	StatusFlagged     = "flagged"     // widoczny, oznaczony do weryfikacji
+	StatusUnpublished = "unpublished" // ukryty na liście produktów i wyłączony ze sprzedaży
+	StatusPending     = "pending"     // czeka na decyzję moderatora (tryb kwarantanny blacklisty)
+	StatusRejected    = "rejected"    // odrzucony przez moderatora
+)
+
 type Product struct {
-	ID          uint    `gorm:"primaryKey"`
-	Name        string  `gorm:"size:255;not null;unique"`
-	Category    string  `gorm:"size:50;not null"`
-	Description string  `gorm:"size:1000"`
-	Price       float64 `gorm:"not null"`
-	Quantity    int     `gorm:"not null;default:0"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
+	ID              uint    `gorm:"primaryKey"`
+	Name            string  `gorm:"size:255;not null;unique"`
+	Category        string  `gorm:"size:50;not null"`
+	Description     string  `gorm:"size:1000"`
+	Price           float64 `gorm:"not null"`
+	Quantity        int     `gorm:"not null;default:0"`
+	Reserved        int     `gorm:"not null;default:0"` // suma aktywnych rezerwacji
+	ReorderPoint    int     `gorm:"not null;default:0"` // próg niskiego stanu, 0 - bez alertów
+	ReorderQuantity int     `gorm:"not null;default:0"` // sugerowana ilość zamówienia po osiągnięciu progu
+	Version         uint    `gorm:"not null;default:1"`
+	Status          string  `gorm:"size:20;not null;default:active;index"`
+	ModerationNote  string  `gorm:"size:1000"` // naruszenie blacklisty, które skierowało produkt do moderacji
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
+}
+
+// Available - Ilość dostępna do sprzedaży: stan minus rezerwacje
+func (p Product) Available() int {
+	return p.Quantity - p.Reserved
+}
+
+// MarshalJSON - Dołącza do produktu wyliczone pole Available
+func (p Product) MarshalJSON() ([]byte, error) {
+	type product Product
+	return json.Marshal(struct {
+		product
+		Available int
+	}{product(p), p.Available()})
+}
+
+// Quarantined - Produkt czeka na decyzję moderatora albo został odrzucony; nie jest publicznie widoczny
+// ani dostępny do sprzedaży
+func (p Product) Quarantined() bool {
+	return p.Status == StatusPending || p.Status == StatusRejected
+}
+
+// Sellable - Produkt można rezerwować i sprzedawać: tylko widoczny w katalogu
+func (p Product) Sellable() bool {
+	return p.Status == StatusActive || p.Status == StatusFlagged
+}
+
+// LowStock - Stan produktu osiągnął próg zamówienia
+func (p Product) LowStock() bool {
+	return p.ReorderPoint > 0 && p.Quantity <= p.ReorderPoint
 }
@@ -4,9 +4,21 @@ import "time"
 
 type ProductHistory struct {
 	ID        uint      `gorm:"primaryKey"`
-	ProductID uint      `gorm:"not null"`
-	Field     string    `gorm:"size:50;not null"` // Price, Name, Quantity, Description
+	ProductID uint      `gorm:"not null;index"`
+	Field     string    `gorm:"size:50;not null"` // Price, Name, Category, Quantity, Description, Deleted
 	OldValue  string    `gorm:"not null"`
 	NewValue  string    `gorm:"not null"`
 	ChangedAt time.Time `gorm:"autoCreateTime"`
+	AuditInfo `gorm:"embedded"`
+	PrevHash  string `gorm:"size:64"` // Hash poprzedniego wpisu tego samego produktu
+	Hash      string `gorm:"size:64"` // SHA-256 treści wpisu i PrevHash
+}
+
+// ProductHistoryHead - Koniec łańcucha historii produktu, zapisywany razem z każdym wpisem; pozwala wykryć
+// usunięcie najnowszych wpisów albo całej historii produktu. Zostaje także po trwałym usunięciu produktu
+type ProductHistoryHead struct {
+	ProductID uint   `gorm:"primaryKey;autoIncrement:false"`
+	HistoryID uint   `gorm:"not null"`
+	Hash      string `gorm:"size:64;not null"`
+	UpdatedAt time.Time
 }
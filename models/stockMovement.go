@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Rodzaje ruchów magazynowych
+const (
+	MovementReceipt    = "receipt"    // przyjęcie, ilość dodatnia
+	MovementSale       = "sale"       // sprzedaż, ilość ujemna
+	MovementAdjustment = "adjustment" // korekta, dowolny znak
+	MovementReturn     = "return"     // zwrot, ilość dodatnia
+	MovementWriteOff   = "write_off"  // odpis, ilość ujemna
+	MovementTransfer   = "transfer"   // przesunięcie między magazynami, para ruchów o przeciwnych znakach
+)
+
+// StockMovement - Ruch magazynowy; Quantity produktu to suma ruchów
+type StockMovement struct {
+	ID           uint      `gorm:"primaryKey"`
+	ProductID    uint      `gorm:"not null;index"`
+	WarehouseID  uint      `gorm:"index"`
+	Type         string    `gorm:"size:20;not null"`
+	Quantity     int       `gorm:"not null"` // Zmiana stanu ze znakiem
+	BalanceAfter int       `gorm:"not null"` // Łączny stan produktu po ruchu
+	Reference    string    `gorm:"size:100"` // Np. numer dokumentu lub zamówienia
+	CreatedAt    time.Time `gorm:"autoCreateTime;index"`
+	AuditInfo    `gorm:"embedded"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Statusy rezerwacji
+const (
+	ReservationActive    = "active"    // wstrzymuje ilość produktu
+	ReservationConfirmed = "confirmed" // zamieniona na sprzedaż
+	ReservationReleased  = "released"  // zwolniona przez klienta
+	ReservationExpired   = "expired"   // zwolniona po upływie ważności
+)
+
+// StockReservation - Czasowa blokada części stanu produktu, np. na czas płatności
+type StockReservation struct {
+	ID          uint      `gorm:"primaryKey"`
+	ProductID   uint      `gorm:"not null;index"`
+	WarehouseID uint      `gorm:"index"`
+	Quantity    int       `gorm:"not null"`
+	Status      string    `gorm:"size:20;not null;default:active;index:idx_reservation_status_expiry"`
+	ExpiresAt   time.Time `gorm:"not null;index:idx_reservation_status_expiry"`
+	Reference   string    `gorm:"size:100"` // Np. identyfikator koszyka lub zamówienia
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	AuditInfo   `gorm:"embedded"`
+	Closed      AuditInfo `gorm:"embedded;embeddedPrefix:closed_"` // kto i dlaczego potwierdził, zwolnił lub wygasił rezerwację
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DefaultWarehouseCode - Magazyn, którego dotyczą zmiany ilości bez wskazanej lokalizacji
+const DefaultWarehouseCode = "MAIN"
+
+type Warehouse struct {
+	ID        uint   `gorm:"primaryKey"`
+	Code      string `gorm:"size:20;not null;unique"`
+	Name      string `gorm:"size:100;not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// StockLevel - Stan produktu w jednym magazynie; Quantity i Reserved produktu to sumy po magazynach
+type StockLevel struct {
+	ID          uint      `gorm:"primaryKey"`
+	ProductID   uint      `gorm:"not null;uniqueIndex:idx_stock_level_product_warehouse"`
+	WarehouseID uint      `gorm:"not null;uniqueIndex:idx_stock_level_product_warehouse;index"`
+	Warehouse   Warehouse `gorm:"foreignKey:WarehouseID"`
+	Quantity    int       `gorm:"not null;default:0"`
+	Reserved    int       `gorm:"not null;default:0"`
+	UpdatedAt   time.Time
+}
+
+// Available - Ilość dostępna w magazynie: stan minus rezerwacje
+func (l StockLevel) Available() int {
+	return l.Quantity - l.Reserved
+}
+
+// MarshalJSON - Dołącza do stanu magazynowego wyliczone pole Available
+func (l StockLevel) MarshalJSON() ([]byte, error) {
+	type stockLevel StockLevel
+	return json.Marshal(struct {
+		stockLevel
+		Available int
+	}{stockLevel(l), l.Available()})
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"product-controller/models"
+	"time"
+)
+
+// LowStockAlertFilter - Kryteria listy alertów niskiego stanu; puste pola nie zawężają wyniku
+type LowStockAlertFilter struct {
+	Status    string
+	ProductID uint
+}
+
+func (r *ProductRepository) CreateLowStockAlert(alert *models.LowStockAlert) error {
+	result := r.DB.Create(alert)
+	return result.Error
+}
+
+// HasOpenLowStockAlert - Czy produkt ma niepotwierdzony alert niskiego stanu
+func (r *ProductRepository) HasOpenLowStockAlert(productID uint) (bool, error) {
+	var count int64
+	result := r.DB.Model(&models.LowStockAlert{}).
+		Where("product_id = ? AND status = ?", productID, models.AlertOpen).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// FindLowStockAlerts - Alerty niskiego stanu od najnowszego
+func (r *ProductRepository) FindLowStockAlerts(filter LowStockAlertFilter, limit int) ([]models.LowStockAlert, error) {
+	var alerts []models.LowStockAlert
+	db := r.DB.Order("id DESC")
+
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.ProductID != 0 {
+		db = db.Where("product_id = ?", filter.ProductID)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	result := db.Find(&alerts)
+	return alerts, result.Error
+}
+
+func (r *ProductRepository) GetLowStockAlertByID(id uint) (*models.LowStockAlert, error) {
+	var alert models.LowStockAlert
+	result := r.DB.First(&alert, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &alert, nil
+}
+
+// AcknowledgeLowStockAlert - Potwierdzenie otwartego alertu; zwraca false, gdy alert był już potwierdzony
+func (r *ProductRepository) AcknowledgeLowStockAlert(alert *models.LowStockAlert, actor string, now time.Time) (bool, error) {
+	result := r.DB.Model(alert).
+		Where("status = ?", models.AlertOpen).
+		Updates(map[string]interface{}{
+			"status":          models.AlertAcknowledged,
+			"acknowledged_at": now,
+			"acknowledged_by": actor,
+		})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+
+	alert.Status = models.AlertAcknowledged
+	alert.AcknowledgedAt = &now
+	alert.AcknowledgedBy = actor
+	return true, nil
+}
+
+// FindUndeliveredLowStockAlerts - Alerty bez wysłanego powiadomienia, którym zostały jeszcze próby, od najstarszego
+func (r *ProductRepository) FindUndeliveredLowStockAlerts(maxAttempts int, limit int) ([]models.LowStockAlert, error) {
+	var alerts []models.LowStockAlert
+	result := r.DB.Where("notified_at IS NULL AND notify_attempts < ?", maxAttempts).
+		Order("id").
+		Limit(limit).
+		Find(&alerts)
+	return alerts, result.Error
+}
+
+// SaveLowStockAlertDelivery - Zapis wyniku próby wysłania powiadomienia; pusty notifyErr oznacza sukces
+func (r *ProductRepository) SaveLowStockAlertDelivery(alert *models.LowStockAlert, now time.Time, notifyErr string) error {
+	updates := map[string]interface{}{
+		"notify_attempts": alert.NotifyAttempts + 1,
+		"notify_error":    notifyErr,
+	}
+	if notifyErr == "" {
+		updates["notified_at"] = now
+	}
+
+	result := r.DB.Model(alert).Updates(updates)
+	return result.Error
+}
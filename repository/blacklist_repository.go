@@ -3,6 +3,7 @@ package repository
 import (
 	"product-controller/config"
 	"product-controller/models"
+	"strings"
 
 	"gorm.io/gorm"
 )
@@ -17,12 +18,42 @@ func NewBlacklistRepository() *BlacklistRepository {
 	}
 }
 
+// Transaction - Uruchamia fn z repozytorium działającym w transakcji
+func (r *BlacklistRepository) Transaction(fn func(repo *BlacklistRepository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&BlacklistRepository{DB: tx})
+	})
+}
+
 func (r *BlacklistRepository) GetAllBlacklistWords() ([]models.BlacklistWord, error) {
 	var words []models.BlacklistWord
 	result := r.DB.Find(&words)
 	return words, result.Error
 }
 
+func (r *BlacklistRepository) GetBlacklistWordByID(id uint) (*models.BlacklistWord, error) {
+	var word models.BlacklistWord
+	result := r.DB.First(&word, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &word, nil
+}
+
+// GetBlacklistWordByWord - Wyszukiwanie bez rozróżniania wielkości liter, łącznie ze słowami usuniętymi
+func (r *BlacklistRepository) GetBlacklistWordByWord(word string) (*models.BlacklistWord, error) {
+	var blacklistWord models.BlacklistWord
+	result := r.DB.Unscoped().Where("LOWER(word) = ?", strings.ToLower(word)).First(&blacklistWord)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &blacklistWord, nil
+}
+
 func (r *BlacklistRepository) AddBlacklistWord(word *models.BlacklistWord) error {
 	result := r.DB.Create(word)
 	return result.Error
@@ -32,3 +63,58 @@ func (r *BlacklistRepository) DeleteBlacklistWord(id uint) error {
 	result := r.DB.Delete(&models.BlacklistWord{}, id)
 	return result.Error
 }
+
+func (r *BlacklistRepository) GetDeletedBlacklistWordByID(id uint) (*models.BlacklistWord, error) {
+	var word models.BlacklistWord
+	result := r.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&word, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &word, nil
+}
+
+func (r *BlacklistRepository) RestoreBlacklistWord(word *models.BlacklistWord) error {
+	result := r.DB.Unscoped().Model(word).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	word.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// BlacklistHistoryFilter - Kryteria wyszukiwania w historii blacklisty
+type BlacklistHistoryFilter struct {
+	WordID uint
+	Action string
+	Actor  string
+}
+
+func (r *BlacklistRepository) SaveBlacklistHistory(history *models.BlacklistHistory) error {
+	result := r.DB.Create(history)
+	return result.Error
+}
+
+// FindBlacklistHistory - Zmiany blacklisty od najnowszej
+func (r *BlacklistRepository) FindBlacklistHistory(filter BlacklistHistoryFilter, limit int) ([]models.BlacklistHistory, error) {
+	var history []models.BlacklistHistory
+	db := r.DB.Order("id DESC")
+
+	if filter.WordID != 0 {
+		db = db.Where("word_id = ?", filter.WordID)
+	}
+	if filter.Action != "" {
+		db = db.Where("action = ?", filter.Action)
+	}
+	if filter.Actor != "" {
+		db = db.Where("actor = ?", filter.Actor)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	result := db.Find(&history)
+	return history, result.Error
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"product-controller/config"
+	"product-controller/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// DefaultCategories - Kategorie zakładane przy pierwszym uruchomieniu
+var DefaultCategories = []models.Category{
+	{Name: "Elektronika", MinPrice: 50, MaxPrice: 50000},
+	{Name: "Książki", MinPrice: 5, MaxPrice: 500},
+	{Name: "Odzież", MinPrice: 10, MaxPrice: 5000},
+}
+
+type CategoryRepository struct {
+	DB *gorm.DB
+}
+
+func NewCategoryRepository() *CategoryRepository {
+	return &CategoryRepository{
+		DB: config.DB,
+	}
+}
+
+func (r *CategoryRepository) GetAllCategories() ([]models.Category, error) {
+	var categories []models.Category
+	result := r.DB.Order("name").Find(&categories)
+	return categories, result.Error
+}
+
+func (r *CategoryRepository) GetCategoryByID(id uint) (*models.Category, error) {
+	var category models.Category
+	result := r.DB.First(&category, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetCategoryByName(name string) (*models.Category, error) {
+	var category models.Category
+	result := r.DB.Where("LOWER(name) = ?", strings.ToLower(name)).First(&category)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepository) CreateCategory(category *models.Category) error {
+	result := r.DB.Create(category)
+	return result.Error
+}
+
+func (r *CategoryRepository) UpdateCategory(category *models.Category) error {
+	result := r.DB.Save(category)
+	return result.Error
+}
+
+func (r *CategoryRepository) DeleteCategory(id uint) error {
+	result := r.DB.Delete(&models.Category{}, id)
+	return result.Error
+}
+
+// CountProductsInCategory - Liczy również produkty w koszu, bo można je przywrócić
+func (r *CategoryRepository) CountProductsInCategory(name string) (int64, error) {
+	var count int64
+	result := r.DB.Unscoped().Model(&models.Product{}).Where("LOWER(category) = ?", strings.ToLower(name)).Count(&count)
+	return count, result.Error
+}
+
+// EnsureDefaultCategories - Zakłada domyślne kategorie, jeśli tabela jest pusta
+func (r *CategoryRepository) EnsureDefaultCategories() error {
+	var count int64
+	if err := r.DB.Model(&models.Category{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	categories := make([]models.Category, len(DefaultCategories))
+	copy(categories, DefaultCategories)
+	return r.DB.Create(&categories).Error
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"product-controller/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// HistoryFilter - Kryteria filtrowania historii zmian; ProductID 0 oznacza wszystkie produkty
+type HistoryFilter struct {
+	ProductID uint
+	Field     string
+	Actor     string
+	From      *time.Time
+	To        *time.Time
+}
+
+// HistoryQuery - Zapytanie o historię; stronicowanie kursorem po ID wpisu
+type HistoryQuery struct {
+	Filter HistoryFilter
+	Limit  int
+	Keyset *Keyset
+}
+
+func applyHistoryFilter(db *gorm.DB, f HistoryFilter) *gorm.DB {
+	if f.ProductID != 0 {
+		db = db.Where("product_id = ?", f.ProductID)
+	}
+	if f.Field != "" {
+		db = db.Where("field = ?", f.Field)
+	}
+	if f.Actor != "" {
+		db = db.Where("actor = ?", f.Actor)
+	}
+	if f.From != nil {
+		db = db.Where("changed_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		db = db.Where("changed_at <= ?", *f.To)
+	}
+	return db
+}
+
+func (r *ProductRepository) FindProductHistory(query HistoryQuery) ([]models.ProductHistory, error) {
+	var history []models.ProductHistory
+	db := applyHistoryFilter(r.DB.Model(&models.ProductHistory{}), query.Filter)
+	db = applyKeyset(db, query.Keyset)
+
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	result := db.Find(&history)
+	return history, result.Error
+}
+
+func (r *ProductRepository) CountProductHistory(filter HistoryFilter) (int64, error) {
+	var count int64
+	result := applyHistoryFilter(r.DB.Model(&models.ProductHistory{}), filter).Count(&count)
+	return count, result.Error
+}
+
+// GetLastProductHistory - Ostatni wpis historii produktu albo nil, jeśli historii brak
+func (r *ProductRepository) GetLastProductHistory(productID uint) (*models.ProductHistory, error) {
+	var history []models.ProductHistory
+	result := r.DB.Where("product_id = ?", productID).Order("id DESC").Limit(1).Find(&history)
+	if result.Error != nil || len(history) == 0 {
+		return nil, result.Error
+	}
+	return &history[0], nil
+}
+
+// SaveProductHistoryHead - Zapis końca łańcucha historii produktu (wstawienie albo nadpisanie)
+func (r *ProductRepository) SaveProductHistoryHead(head *models.ProductHistoryHead) error {
+	result := r.DB.Clauses(clause.OnConflict{UpdateAll: true}).Create(head)
+	return result.Error
+}
+
+// GetProductHistoryHeads - Zapisane końce łańcuchów jednego produktu albo (productID 0) wszystkich
+func (r *ProductRepository) GetProductHistoryHeads(productID uint) ([]models.ProductHistoryHead, error) {
+	var heads []models.ProductHistoryHead
+	db := r.DB.Order("product_id")
+	if productID != 0 {
+		db = db.Where("product_id = ?", productID)
+	}
+	result := db.Find(&heads)
+	return heads, result.Error
+}
+
+// EnsureProductHistoryHeads - Zapisuje koniec łańcucha produktom, których historia powstała przed wprowadzeniem
+// tej tabeli: końcem staje się ich ostatni wpis z hashem
+func (r *ProductRepository) EnsureProductHistoryHeads() error {
+	return r.DB.Exec(`INSERT INTO product_history_heads (product_id, history_id, hash, updated_at)
+		SELECT h.product_id, h.id, h.hash, NOW() FROM product_histories h
+		JOIN (SELECT product_id, MAX(id) AS id FROM product_histories WHERE hash <> '' GROUP BY product_id) last ON last.id = h.id
+		WHERE NOT EXISTS (SELECT 1 FROM product_history_heads ph WHERE ph.product_id = h.product_id)`).Error
+}
+
+// GetHistoryChainBatch - Kolejna porcja wpisów w kolejności (product_id, id), zaczynając za wskazanym wpisem
+func (r *ProductRepository) GetHistoryChainBatch(productID uint, afterProductID, afterID uint, limit int) ([]models.ProductHistory, error) {
+	var history []models.ProductHistory
+	db := r.DB.Where("((product_id > ?) OR (product_id = ? AND id > ?))", afterProductID, afterProductID, afterID)
+	if productID != 0 {
+		db = db.Where("product_id = ?", productID)
+	}
+	result := db.Order("product_id ASC").Order("id ASC").Limit(limit).Find(&history)
+	return history, result.Error
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ProductFilter - Kryteria filtrowania listy produktów
+type ProductFilter struct {
+	Category     string
+	MinPrice     *float64
+	MaxPrice     *float64
+	MinQuantity  *int
+	MaxQuantity  *int
+	NamePrefix   string
+	NameContains string
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	UpdatedFrom  *time.Time
+	UpdatedTo    *time.Time
+	Statuses     []string // puste - wszystkie statusy
+}
+
+// SortField - Pojedyncze pole sortowania
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset - Pozycja kursora dla stronicowania po ID albo UpdatedAt
+type Keyset struct {
+	Column    string // "id" albo "updated_at"
+	Desc      bool
+	Backward  bool // true - poprzednia strona
+	ID        uint
+	UpdatedAt time.Time
+}
+
+// ProductQuery - Zapytanie o listę produktów
+type ProductQuery struct {
+	Filter ProductFilter
+	Sort   []SortField
+	Limit  int
+	Offset int
+	Keyset *Keyset
+}
+
+// ProductSortColumns - Kolumny, po których można sortować produkty
+var ProductSortColumns = map[string]string{
+	"id":         "id",
+	"name":       "name",
+	"category":   "category",
+	"price":      "price",
+	"quantity":   "quantity",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"status":     "status",
+}
+
+func applyProductFilter(db *gorm.DB, f ProductFilter) *gorm.DB {
+	if f.Category != "" {
+		db = db.Where("LOWER(category) = ?", strings.ToLower(f.Category))
+	}
+	if f.MinPrice != nil {
+		db = db.Where("price >= ?", *f.MinPrice)
+	}
+	if f.MaxPrice != nil {
+		db = db.Where("price <= ?", *f.MaxPrice)
+	}
+	if f.MinQuantity != nil {
+		db = db.Where("quantity >= ?", *f.MinQuantity)
+	}
+	if f.MaxQuantity != nil {
+		db = db.Where("quantity <= ?", *f.MaxQuantity)
+	}
+	if f.NamePrefix != "" {
+		db = db.Where("name LIKE ?", escapeLike(f.NamePrefix)+"%")
+	}
+	if f.NameContains != "" {
+		db = db.Where("name LIKE ?", "%"+escapeLike(f.NameContains)+"%")
+	}
+	if f.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *f.CreatedFrom)
+	}
+	if f.CreatedTo != nil {
+		db = db.Where("created_at <= ?", *f.CreatedTo)
+	}
+	if f.UpdatedFrom != nil {
+		db = db.Where("updated_at >= ?", *f.UpdatedFrom)
+	}
+	if f.UpdatedTo != nil {
+		db = db.Where("updated_at <= ?", *f.UpdatedTo)
+	}
+	if len(f.Statuses) > 0 {
+		db = db.Where("status IN ?", f.Statuses)
+	}
+	return db
+}
+
+func applyKeyset(db *gorm.DB, k *Keyset) *gorm.DB {
+	// Przy cofaniu się o stronę odwracamy kierunek, a wynik odwracamy później
+	desc := k.Desc != k.Backward
+	op, dir := ">", "ASC"
+	if desc {
+		op, dir = "<", "DESC"
+	}
+
+	if k.Column == "updated_at" {
+		if k.ID != 0 {
+			db = db.Where("((updated_at "+op+" ?) OR (updated_at = ? AND id "+op+" ?))", k.UpdatedAt, k.UpdatedAt, k.ID)
+		}
+		return db.Order("updated_at " + dir).Order("id " + dir)
+	}
+
+	if k.ID != 0 {
+		db = db.Where("id "+op+" ?", k.ID)
+	}
+	return db.Order("id " + dir)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -1,12 +1,17 @@
 package repository
 
 import (
+	"errors"
 	"product-controller/config"
 	"product-controller/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// ErrVersionConflict - Produkt w bazie ma inną wersję niż oczekiwana
+var ErrVersionConflict = errors.New("produkt został w międzyczasie zmieniony (niezgodna wersja)")
+
 type ProductRepository struct {
 	DB *gorm.DB
 }
@@ -17,6 +22,13 @@ func NewProductRepository() *ProductRepository {
 	}
 }
 
+// Transaction - Wykonuje fn w transakcji, przekazując repozytorium działające na tej transakcji
+func (r *ProductRepository) Transaction(fn func(repo *ProductRepository) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&ProductRepository{DB: tx})
+	})
+}
+
 func (r *ProductRepository) CreateProduct(product *models.Product) error {
 	result := r.DB.Create(product)
 	return result.Error
@@ -40,13 +52,129 @@ func (r *ProductRepository) GetAllProducts() ([]models.Product, error) {
 	return products, result.Error
 }
 
+func (r *ProductRepository) FindProducts(query ProductQuery) ([]models.Product, error) {
+	var products []models.Product
+	db := applyProductFilter(r.DB.Model(&models.Product{}), query.Filter)
+
+	if query.Keyset != nil {
+		db = applyKeyset(db, query.Keyset)
+	} else {
+		for _, s := range query.Sort {
+			if s.Desc {
+				db = db.Order(s.Column + " DESC")
+			} else {
+				db = db.Order(s.Column + " ASC")
+			}
+		}
+		db = db.Offset(query.Offset)
+	}
+
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+
+	result := db.Find(&products)
+	return products, result.Error
+}
+
+func (r *ProductRepository) CountProducts(filter ProductFilter) (int64, error) {
+	var count int64
+	result := applyProductFilter(r.DB.Model(&models.Product{}), filter).Count(&count)
+	return count, result.Error
+}
+
+// UpdateProduct - Zapis produktu pod warunkiem, że w bazie nadal jest wersja product.Version, a nowa ilość
+// nie jest mniejsza niż zarezerwowana; zwiększa wersję
 func (r *ProductRepository) UpdateProduct(product *models.Product) error {
-	result := r.DB.Save(product)
-	return result.Error
+	version := product.Version
+	product.Version = version + 1
+
+	result := r.DB.Model(product).
+		Where("version = ? AND reserved <= ?", version, product.Quantity).
+		Select("Name", "Category", "Description", "Price", "Quantity", "ReorderPoint", "ReorderQuantity", "Status", "ModerationNote", "Version", "UpdatedAt").
+		Updates(product)
+	if result.Error != nil {
+		product.Version = version
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		product.Version = version
+		// Wersja się zgadza, więc zapis zablokowały rezerwacje
+		if current, err := r.GetProductByID(product.ID); err == nil && current.Version == version {
+			return ErrBelowReserved
+		}
+		return ErrVersionConflict
+	}
+
+	return nil
+}
+
+// SetProductStatus - Zmiana statusu publikacji i notatki moderacyjnej, warunkowa względem wersji produktu
+func (r *ProductRepository) SetProductStatus(product *models.Product, status, note string) error {
+	result := r.DB.Model(product).
+		Where("version = ?", product.Version).
+		Updates(map[string]interface{}{"status": status, "moderation_note": note, "version": product.Version + 1})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	product.Version++
+	product.Status = status
+	product.ModerationNote = note
+	return nil
+}
+
+func (r *ProductRepository) DeleteProduct(id uint, version uint) error {
+	result := r.DB.Where("version = ?", version).Delete(&models.Product{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetDeletedProducts() ([]models.Product, error) {
+	var products []models.Product
+	result := r.DB.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&products)
+	return products, result.Error
+}
+
+func (r *ProductRepository) GetDeletedProductByID(id uint) (*models.Product, error) {
+	var product models.Product
+	result := r.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&product, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &product, nil
 }
 
-func (r *ProductRepository) DeleteProduct(id uint) error {
-	result := r.DB.Delete(&models.Product{}, id)
+// RestoreProduct - Zdjęcie znacznika usunięcia z produktu w koszu
+func (r *ProductRepository) RestoreProduct(product *models.Product) error {
+	result := r.DB.Unscoped().Model(product).
+		Where("version = ? AND deleted_at IS NOT NULL", product.Version).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": product.Version + 1})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	product.Version++
+	product.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+// PurgeProduct - Trwałe usunięcie produktu z kosza
+func (r *ProductRepository) PurgeProduct(id uint) error {
+	result := r.DB.Unscoped().Where("deleted_at IS NOT NULL").Delete(&models.Product{}, id)
 	return result.Error
 }
 
@@ -55,12 +183,50 @@ func (r *ProductRepository) SaveProductHistory(history *models.ProductHistory) e
 	return result.Error
 }
 
-func (r *ProductRepository) GetProductHistory(productID uint) ([]models.ProductHistory, error) {
+func (r *ProductRepository) GetProductHistoryEntry(id uint) (*models.ProductHistory, error) {
+	var history models.ProductHistory
+	result := r.DB.First(&history, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &history, nil
+}
+
+// GetProductHistoryAfterID - Wpisy nowsze niż wskazany, od najnowszego
+func (r *ProductRepository) GetProductHistoryAfterID(productID uint, historyID uint) ([]models.ProductHistory, error) {
+	var history []models.ProductHistory
+	result := r.DB.Where("product_id = ? AND id > ?", productID, historyID).Order("id DESC").Find(&history)
+	return history, result.Error
+}
+
+// GetProductHistoryAfter - Wpisy zapisane po chwili t, od najnowszego
+func (r *ProductRepository) GetProductHistoryAfter(productID uint, t time.Time) ([]models.ProductHistory, error) {
 	var history []models.ProductHistory
-	result := r.DB.Where("product_id = ?", productID).Find(&history)
+	result := r.DB.Where("product_id = ? AND changed_at > ?", productID, t).Order("id DESC").Find(&history)
 	return history, result.Error
 }
 
+// GetProductHistoryBetween - Wpisy zapisane w przedziale (from, to], od najnowszego
+func (r *ProductRepository) GetProductHistoryBetween(productID uint, from, to time.Time) ([]models.ProductHistory, error) {
+	var history []models.ProductHistory
+	result := r.DB.Where("product_id = ? AND changed_at > ? AND changed_at <= ?", productID, from, to).Order("id DESC").Find(&history)
+	return history, result.Error
+}
+
+// GetProductByIDUnscoped - Wyszukanie po ID z uwzględnieniem produktów w koszu
+func (r *ProductRepository) GetProductByIDUnscoped(id uint) (*models.Product, error) {
+	var product models.Product
+	result := r.DB.Unscoped().First(&product, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &product, nil
+}
+
 func (r *ProductRepository) GetProductByName(name string) (*models.Product, error) {
 	var product models.Product
 	result := r.DB.Where("name = ?", name).First(&product)
@@ -71,3 +237,15 @@ func (r *ProductRepository) GetProductByName(name string) (*models.Product, erro
 
 	return &product, nil
 }
+
+// GetProductByNameUnscoped - Wyszukanie po nazwie z uwzględnieniem produktów w koszu
+func (r *ProductRepository) GetProductByNameUnscoped(name string) (*models.Product, error) {
+	var product models.Product
+	result := r.DB.Unscoped().Where("name = ?", name).First(&product)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &product, nil
+}
@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"errors"
+	"product-controller/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrNegativeStock - Zmiana stanu, po której ilość produktu byłaby ujemna
+	ErrNegativeStock = errors.New("ilość produktów nie może być ujemna")
+	// ErrBelowReserved - Zmiana stanu, po której ilość produktu byłaby mniejsza niż zarezerwowana
+	ErrBelowReserved = errors.New("ilość produktów nie może być mniejsza niż ilość zarezerwowana")
+	// ErrInsufficientStock - Dostępna ilość (stan minus rezerwacje) nie wystarcza na rezerwację
+	ErrInsufficientStock = errors.New("niewystarczająca dostępna ilość produktu")
+	// ErrInsufficientWarehouseStock - Zmiana stanu, po której ilość w magazynie byłaby ujemna
+	ErrInsufficientWarehouseStock = errors.New("niewystarczająca ilość produktu w magazynie")
+)
+
+// AdjustProductQuantity - Atomowa zmiana stanu produktu o delta wraz ze zwiększeniem wersji; warunek w UPDATE
+// odrzuca zmianę, po której stan byłby ujemny lub mniejszy niż rezerwacje. Wersja 0 oznacza dowolną.
+// Zwraca produkt po zmianie
+func (r *ProductRepository) AdjustProductQuantity(id uint, delta int, version uint) (*models.Product, error) {
+	db := r.DB.Model(&models.Product{}).Where("id = ? AND quantity + ? >= reserved", id, delta)
+	if version != 0 {
+		db = db.Where("version = ?", version)
+	}
+
+	result := db.Updates(map[string]interface{}{
+		"quantity": gorm.Expr("quantity + ?", delta),
+		"version":  gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	product, err := r.GetProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if result.RowsAffected == 0 {
+		if version != 0 && product.Version != version {
+			return nil, ErrVersionConflict
+		}
+		if product.Quantity+delta < 0 {
+			return nil, ErrNegativeStock
+		}
+		return nil, ErrBelowReserved
+	}
+
+	return product, nil
+}
+
+func (r *ProductRepository) SaveStockMovement(movement *models.StockMovement) error {
+	result := r.DB.Create(movement)
+	return result.Error
+}
+
+// FindStockMovements - Ruchy magazynowe produktu od najnowszego, opcjonalnie tylko wskazanego rodzaju
+func (r *ProductRepository) FindStockMovements(productID uint, movementType string, limit int) ([]models.StockMovement, error) {
+	var movements []models.StockMovement
+	db := r.DB.Where("product_id = ?", productID).Order("id DESC")
+
+	if movementType != "" {
+		db = db.Where("type = ?", movementType)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	result := db.Find(&movements)
+	return movements, result.Error
+}
+
+// ReserveProductQuantity - Zwiększenie rezerwacji, o ile dostępna ilość na to pozwala. Rezerwacja nie zmienia
+// wersji produktu ani daty modyfikacji
+func (r *ProductRepository) ReserveProductQuantity(id uint, quantity int) error {
+	result := r.DB.Model(&models.Product{}).
+		Where("id = ? AND quantity - reserved >= ?", id, quantity).
+		UpdateColumn("reserved", gorm.Expr("reserved + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// ReleaseProductQuantity - Zmniejszenie rezerwacji; dotyczy też produktów w koszu
+func (r *ProductRepository) ReleaseProductQuantity(id uint, quantity int) error {
+	result := r.DB.Unscoped().Model(&models.Product{}).
+		Where("id = ?", id).
+		UpdateColumn("reserved", gorm.Expr("reserved - ?", quantity))
+	return result.Error
+}
+
+// ConsumeReservedQuantity - Wydanie zarezerwowanej ilości: zmniejsza stan i rezerwację, zwiększa wersję.
+// Zwraca produkt po zmianie
+func (r *ProductRepository) ConsumeReservedQuantity(id uint, quantity int) (*models.Product, error) {
+	result := r.DB.Model(&models.Product{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", quantity),
+			"reserved": gorm.Expr("reserved - ?", quantity),
+			"version":  gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.GetProductByID(id)
+}
+
+func (r *ProductRepository) CreateReservation(reservation *models.StockReservation) error {
+	result := r.DB.Create(reservation)
+	return result.Error
+}
+
+func (r *ProductRepository) GetReservationByID(id uint) (*models.StockReservation, error) {
+	var reservation models.StockReservation
+	result := r.DB.First(&reservation, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &reservation, nil
+}
+
+// CloseReservation - Zamknięcie aktywnej rezerwacji nadaniem statusu. Warunek na status sprawia, że z równoległych
+// prób (potwierdzenie, zwolnienie, wygaśnięcie) skuteczna jest tylko jedna; potwierdzić można tylko rezerwację
+// ważną w chwili now, a wygasić tylko przeterminowaną. Zwraca false, gdy rezerwacja nie została zamknięta
+func (r *ProductRepository) CloseReservation(id uint, status string, now time.Time, audit models.AuditInfo) (bool, error) {
+	db := r.DB.Model(&models.StockReservation{}).Where("id = ? AND status = ?", id, models.ReservationActive)
+	switch status {
+	case models.ReservationConfirmed:
+		db = db.Where("expires_at > ?", now)
+	case models.ReservationExpired:
+		db = db.Where("expires_at <= ?", now)
+	}
+
+	result := db.Updates(map[string]interface{}{
+		"status":            status,
+		"closed_actor":      audit.Actor,
+		"closed_request_id": audit.RequestID,
+		"closed_source_ip":  audit.SourceIP,
+		"closed_reason":     audit.Reason,
+	})
+	return result.RowsAffected == 1, result.Error
+}
+
+// FindExpiredReservations - Aktywne rezerwacje, których ważność minęła przed chwilą now
+func (r *ProductRepository) FindExpiredReservations(now time.Time, limit int) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	result := r.DB.Where("status = ? AND expires_at <= ?", models.ReservationActive, now).
+		Order("id").
+		Limit(limit).
+		Find(&reservations)
+	return reservations, result.Error
+}
+
+// FindReservations - Rezerwacje produktu od najnowszej, opcjonalnie tylko o wskazanym statusie
+func (r *ProductRepository) FindReservations(productID uint, status string, limit int) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	db := r.DB.Where("product_id = ?", productID).Order("id DESC")
+
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	result := db.Find(&reservations)
+	return reservations, result.Error
+}
+
+// LockProduct - Odczyt produktu z blokadą wiersza do końca transakcji; porządkuje równoległe zmiany stanów
+// magazynowych tego samego produktu
+func (r *ProductRepository) LockProduct(id uint) (*models.Product, error) {
+	var product models.Product
+	result := r.DB.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &product, nil
+}
+
+// AdjustStockLevel - Zmiana stanu produktu w magazynie o delta; brakujący stan jest zakładany przy zmianie dodatniej.
+// Odrzuca zmianę, po której stan w magazynie byłby ujemny albo mniejszy niż rezerwacje
+func (r *ProductRepository) AdjustStockLevel(productID, warehouseID uint, delta int) error {
+	result := r.DB.Model(&models.StockLevel{}).
+		Where("product_id = ? AND warehouse_id = ? AND quantity + ? >= reserved", productID, warehouseID, delta).
+		Update("quantity", gorm.Expr("quantity + ?", delta))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	var level models.StockLevel
+	err := r.DB.Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).First(&level).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if delta < 0 {
+			return ErrInsufficientWarehouseStock
+		}
+		return r.DB.Create(&models.StockLevel{ProductID: productID, WarehouseID: warehouseID, Quantity: delta}).Error
+	}
+	if err != nil {
+		return err
+	}
+	if level.Quantity+delta < 0 {
+		return ErrInsufficientWarehouseStock
+	}
+	return ErrBelowReserved
+}
+
+// HasStockOutsideWarehouse - Czy produkt ma stan lub rezerwacje w innym magazynie niż wskazany
+func (r *ProductRepository) HasStockOutsideWarehouse(productID, warehouseID uint) (bool, error) {
+	var count int64
+	result := r.DB.Model(&models.StockLevel{}).
+		Where("product_id = ? AND warehouse_id <> ? AND (quantity <> 0 OR reserved <> 0)", productID, warehouseID).
+		Count(&count)
+	return count > 0, result.Error
+}
+
+// ReserveStockLevel - Zwiększenie rezerwacji w magazynie, o ile dostępna w nim ilość na to pozwala
+func (r *ProductRepository) ReserveStockLevel(productID, warehouseID uint, quantity int) error {
+	result := r.DB.Model(&models.StockLevel{}).
+		Where("product_id = ? AND warehouse_id = ? AND quantity - reserved >= ?", productID, warehouseID, quantity).
+		Update("reserved", gorm.Expr("reserved + ?", quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+// ReleaseStockLevel - Zmniejszenie rezerwacji w magazynie
+func (r *ProductRepository) ReleaseStockLevel(productID, warehouseID uint, quantity int) error {
+	result := r.DB.Model(&models.StockLevel{}).
+		Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).
+		Update("reserved", gorm.Expr("reserved - ?", quantity))
+	return result.Error
+}
+
+// ConsumeStockLevel - Wydanie zarezerwowanej ilości z magazynu
+func (r *ProductRepository) ConsumeStockLevel(productID, warehouseID uint, quantity int) error {
+	result := r.DB.Model(&models.StockLevel{}).
+		Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).
+		Updates(map[string]interface{}{
+			"quantity": gorm.Expr("quantity - ?", quantity),
+			"reserved": gorm.Expr("reserved - ?", quantity),
+		})
+	return result.Error
+}
+
+// DeleteStockLevels - Usunięcie stanów magazynowych trwale usuniętego produktu
+func (r *ProductRepository) DeleteStockLevels(productID uint) error {
+	result := r.DB.Where("product_id = ?", productID).Delete(&models.StockLevel{})
+	return result.Error
+}
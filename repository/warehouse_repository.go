@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"errors"
+	"product-controller/config"
+	"product-controller/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type WarehouseRepository struct {
+	DB *gorm.DB
+}
+
+func NewWarehouseRepository() *WarehouseRepository {
+	return &WarehouseRepository{
+		DB: config.DB,
+	}
+}
+
+func (r *WarehouseRepository) GetAllWarehouses() ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	result := r.DB.Order("code").Find(&warehouses)
+	return warehouses, result.Error
+}
+
+func (r *WarehouseRepository) GetWarehouseByID(id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := r.DB.First(&warehouse, id)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &warehouse, nil
+}
+
+func (r *WarehouseRepository) GetWarehouseByCode(code string) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := r.DB.Where("code = ?", strings.ToUpper(code)).First(&warehouse)
+
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	return &warehouse, nil
+}
+
+func (r *WarehouseRepository) CreateWarehouse(warehouse *models.Warehouse) error {
+	result := r.DB.Create(warehouse)
+	return result.Error
+}
+
+// GetStockLevelsByProduct - Stany produktu we wszystkich magazynach
+func (r *WarehouseRepository) GetStockLevelsByProduct(productID uint) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	result := r.DB.Preload("Warehouse").Where("product_id = ?", productID).Order("warehouse_id").Find(&levels)
+	return levels, result.Error
+}
+
+// GetStockLevelsByWarehouse - Stany wszystkich produktów w magazynie
+func (r *WarehouseRepository) GetStockLevelsByWarehouse(warehouseID uint) ([]models.StockLevel, error) {
+	var levels []models.StockLevel
+	result := r.DB.Preload("Warehouse").Where("warehouse_id = ?", warehouseID).Order("product_id").Find(&levels)
+	return levels, result.Error
+}
+
+// EnsureDefaultWarehouse - Zakłada magazyn domyślny i przenosi do niego stany produktów, które nie mają jeszcze
+// stanów magazynowych, oraz ruchy i rezerwacje sprzed podziału na magazyny
+func (r *WarehouseRepository) EnsureDefaultWarehouse() error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var warehouse models.Warehouse
+		err := tx.Where("code = ?", models.DefaultWarehouseCode).First(&warehouse).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			warehouse = models.Warehouse{Code: models.DefaultWarehouseCode, Name: "Magazyn główny"}
+			err = tx.Create(&warehouse).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		err = tx.Exec(`INSERT INTO stock_levels (product_id, warehouse_id, quantity, reserved, updated_at)
+			SELECT p.id, ?, p.quantity, p.reserved, NOW() FROM products p
+			WHERE NOT EXISTS (SELECT 1 FROM stock_levels s WHERE s.product_id = p.id)`, warehouse.ID).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&models.StockMovement{}).Where("warehouse_id = 0").UpdateColumn("warehouse_id", warehouse.ID).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.StockReservation{}).Where("warehouse_id = 0").UpdateColumn("warehouse_id", warehouse.ID).Error
+	})
+}
@@ -0,0 +1,87 @@
+package service
+
+// ahoCorasick - Automat Aho-Corasick: wyszukiwanie wszystkich wzorców w jednym przejściu po tekście
+type ahoCorasick struct {
+	next    []map[rune]int
+	fail    []int
+	outputs [][]int // wzorce kończące się w danym stanie (razem z osiągalnymi przez fail)
+	lengths []int   // długości wzorców w znakach
+}
+
+func newAhoCorasick(patterns [][]rune) *ahoCorasick {
+	ac := &ahoCorasick{
+		next:    []map[rune]int{{}},
+		fail:    []int{0},
+		outputs: [][]int{nil},
+	}
+
+	// Drzewo trie ze wszystkich wzorców
+	for i, pattern := range patterns {
+		ac.lengths = append(ac.lengths, len(pattern))
+		if len(pattern) == 0 {
+			continue
+		}
+
+		state := 0
+		for _, r := range pattern {
+			n, ok := ac.next[state][r]
+			if !ok {
+				n = len(ac.next)
+				ac.next = append(ac.next, map[rune]int{})
+				ac.fail = append(ac.fail, 0)
+				ac.outputs = append(ac.outputs, nil)
+				ac.next[state][r] = n
+			}
+			state = n
+		}
+		ac.outputs[state] = append(ac.outputs[state], i)
+	}
+
+	// Przejścia fail wyznaczane wszerz, od najpłytszych stanów
+	var queue []int
+	for _, n := range ac.next[0] {
+		queue = append(queue, n)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for r, n := range ac.next[state] {
+			f := ac.fail[state]
+			for f != 0 {
+				if _, ok := ac.next[f][r]; ok {
+					break
+				}
+				f = ac.fail[f]
+			}
+			if target, ok := ac.next[f][r]; ok {
+				ac.fail[n] = target
+			}
+			ac.outputs[n] = append(ac.outputs[n], ac.outputs[ac.fail[n]]...)
+			queue = append(queue, n)
+		}
+	}
+
+	return ac
+}
+
+// search - Wywołuje found dla każdego wystąpienia wzorca; pozycje [start, end) w znakach
+func (ac *ahoCorasick) search(text []rune, found func(pattern, start, end int)) {
+	state := 0
+	for i, r := range text {
+		for {
+			if n, ok := ac.next[state][r]; ok {
+				state = n
+				break
+			}
+			if state == 0 {
+				break
+			}
+			state = ac.fail[state]
+		}
+
+		for _, pattern := range ac.outputs[state] {
+			found(pattern, i+1-ac.lengths[pattern], i+1)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"product-controller/models"
+	"product-controller/repository"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlertNotFound     = errors.New("alert nie istnieje")
+	ErrAlertAcknowledged = errors.New("alert został już potwierdzony")
+)
+
+const (
+	AlertDispatchInterval = 10 * time.Second
+
+	// Liczba prób wysłania powiadomienia, po której alert zostaje tylko na liście
+	maxAlertNotifyAttempts = 5
+	alertDispatchBatch     = 100
+)
+
+type AlertService struct {
+	ProductRepo *repository.ProductRepository
+	Notifier    LowStockNotifier
+}
+
+func NewAlertService(productRepo *repository.ProductRepository, notifier LowStockNotifier) *AlertService {
+	return &AlertService{
+		ProductRepo: productRepo,
+		Notifier:    notifier,
+	}
+}
+
+func (s *AlertService) GetLowStockAlerts(filter repository.LowStockAlertFilter, limit int) ([]models.LowStockAlert, error) {
+	return s.ProductRepo.FindLowStockAlerts(filter, limit)
+}
+
+// AcknowledgeLowStockAlert - Potwierdzenie alertu przez autora z kontekstu; kolejne zejście do progu utworzy nowy alert
+func (s *AlertService) AcknowledgeLowStockAlert(ctx context.Context, id uint) (*models.LowStockAlert, error) {
+	alert, err := s.ProductRepo.GetLowStockAlertByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAlertNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	acknowledged, err := s.ProductRepo.AcknowledgeLowStockAlert(alert, AuditInfoFromContext(ctx).Actor, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !acknowledged {
+		return nil, ErrAlertAcknowledged
+	}
+
+	return alert, nil
+}
+
+// DispatchLowStockAlerts - Wysłanie zaległych powiadomień; alerty powstają w transakcjach zmian stanu, a wysyłka
+// następuje dopiero po ich zatwierdzeniu. Zwraca liczbę dostarczonych powiadomień
+func (s *AlertService) DispatchLowStockAlerts(ctx context.Context) (int, error) {
+	alerts, err := s.ProductRepo.FindUndeliveredLowStockAlerts(maxAlertNotifyAttempts, alertDispatchBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for i := range alerts {
+		notifyErr := ""
+		if err = s.Notifier.NotifyLowStock(ctx, alerts[i]); err != nil {
+			notifyErr = err.Error()
+		} else {
+			delivered++
+		}
+
+		if err = s.ProductRepo.SaveLowStockAlertDelivery(&alerts[i], time.Now(), notifyErr); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// RunAlertDispatcher - Cykliczna wysyłka powiadomień o alertach do czasu anulowania ctx
+func (s *AlertService) RunAlertDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchLowStockAlerts(ctx); err != nil {
+				log.Println("Błąd wysyłania alertów niskiego stanu:", err)
+			}
+		}
+	}
+}
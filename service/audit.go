@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"product-controller/models"
+)
+
+type auditKey struct{}
+
+// WithAuditInfo - Dołącza do kontekstu informacje o autorze zmiany
+func WithAuditInfo(ctx context.Context, info models.AuditInfo) context.Context {
+	return context.WithValue(ctx, auditKey{}, info)
+}
+
+// WithReason - Nadpisuje powód zmiany zachowując pozostałe informacje audytowe
+func WithReason(ctx context.Context, reason string) context.Context {
+	info := AuditInfoFromContext(ctx)
+	info.Reason = reason
+	return WithAuditInfo(ctx, info)
+}
+
+func AuditInfoFromContext(ctx context.Context) models.AuditInfo {
+	info, _ := ctx.Value(auditKey{}).(models.AuditInfo)
+	return info
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"product-controller/models"
+	"strings"
+)
+
+// Formaty importu i eksportu blacklisty
+const (
+	BlacklistFormatText = "txt"  // jedno słowo w linii, tryb substring; linie zaczynające się od # są pomijane
+	BlacklistFormatCSV  = "csv"  // kolumny: word, match_mode, fields, categories (nagłówek opcjonalny)
+	BlacklistFormatJSON = "json" // tablica obiektów BlacklistWord
+)
+
+// Wynik importu pojedynczego słowa
+const (
+	ImportCreated   = "created"
+	ImportDuplicate = "duplicate"
+	ImportInvalid   = "invalid"
+)
+
+var blacklistCSVHeader = []string{"word", "match_mode", "fields", "categories"}
+
+// BlacklistImportRow - Słowo odczytane z pliku wraz z numerem linii (dla JSON - pozycją w tablicy, od 1)
+type BlacklistImportRow struct {
+	Line  int
+	Word  models.BlacklistWord
+	Error string // błąd odczytu wiersza
+}
+
+// BlacklistImportResult - Wynik importu jednego wiersza
+type BlacklistImportResult struct {
+	Line   int
+	Word   string
+	Status string
+	ID     uint   `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+// BlacklistImportReport - Podsumowanie importu
+type BlacklistImportReport struct {
+	Created    int
+	Duplicates int
+	Invalid    int
+	Results    []BlacklistImportResult
+}
+
+// ParseBlacklistFormat - Sprawdzenie nazwy formatu
+func ParseBlacklistFormat(format string) (string, error) {
+	switch format {
+	case BlacklistFormatText, BlacklistFormatCSV, BlacklistFormatJSON:
+		return format, nil
+	}
+	return "", fmt.Errorf("nieznany format %q, dozwolone: %s, %s, %s", format,
+		BlacklistFormatText, BlacklistFormatCSV, BlacklistFormatJSON)
+}
+
+// ReadBlacklistImport - Odczyt słów z pliku w danym formacie
+func ReadBlacklistImport(format string, r io.Reader) ([]BlacklistImportRow, error) {
+	switch format {
+	case BlacklistFormatText:
+		return readBlacklistText(r)
+	case BlacklistFormatCSV:
+		return readBlacklistCSV(r)
+	case BlacklistFormatJSON:
+		return readBlacklistJSON(r)
+	}
+	return nil, fmt.Errorf("nieznany format %q", format)
+}
+
+func readBlacklistText(r io.Reader) ([]BlacklistImportRow, error) {
+	var rows []BlacklistImportRow
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		rows = append(rows, BlacklistImportRow{Line: line, Word: models.BlacklistWord{Word: word}})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("błąd odczytu pliku: %w", err)
+	}
+	return rows, nil
+}
+
+func readBlacklistCSV(r io.Reader) ([]BlacklistImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []BlacklistImportRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				return nil, fmt.Errorf("niepoprawny plik CSV: %w", err)
+			}
+			return nil, fmt.Errorf("błąd odczytu pliku: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first && strings.EqualFold(strings.TrimSpace(record[0]), blacklistCSVHeader[0]) {
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row := BlacklistImportRow{Line: line}
+		if len(record) > len(blacklistCSVHeader) {
+			row.Error = fmt.Sprintf("za dużo kolumn: %d, oczekiwano najwyżej %d", len(record), len(blacklistCSVHeader))
+		}
+		for len(record) < len(blacklistCSVHeader) {
+			record = append(record, "")
+		}
+		row.Word = models.BlacklistWord{
+			Word:       strings.TrimSpace(record[0]),
+			MatchMode:  strings.TrimSpace(record[1]),
+			Fields:     strings.TrimSpace(record[2]),
+			Categories: strings.TrimSpace(record[3]),
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readBlacklistJSON(r io.Reader) ([]BlacklistImportRow, error) {
+	var words []models.BlacklistWord
+	if err := json.NewDecoder(r).Decode(&words); err != nil {
+		return nil, fmt.Errorf("niepoprawny plik JSON, oczekiwano tablicy słów: %w", err)
+	}
+
+	rows := make([]BlacklistImportRow, len(words))
+	for i, word := range words {
+		rows[i] = BlacklistImportRow{Line: i + 1, Word: word}
+	}
+	return rows, nil
+}
+
+// WriteBlacklistExport - Zapis słów w danym formacie; format txt zawiera tylko same słowa
+func WriteBlacklistExport(format string, w io.Writer, words []models.BlacklistWord) error {
+	switch format {
+	case BlacklistFormatText:
+		for _, word := range words {
+			if _, err := fmt.Fprintln(w, word.Word); err != nil {
+				return err
+			}
+		}
+		return nil
+	case BlacklistFormatCSV:
+		writer := csv.NewWriter(w)
+		writer.Write(blacklistCSVHeader)
+		for _, word := range words {
+			writer.Write([]string{word.Word, word.MatchMode, word.Fields, word.Categories})
+		}
+		writer.Flush()
+		return writer.Error()
+	case BlacklistFormatJSON:
+		if words == nil {
+			words = []models.BlacklistWord{}
+		}
+		return json.NewEncoder(w).Encode(words)
+	}
+	return fmt.Errorf("nieznany format %q", format)
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"product-controller/models"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// BlacklistViolation - Naruszenie blacklisty: która reguła pasuje i w którym miejscu (pozycje w znakach)
+type BlacklistViolation struct {
+	WordID   uint
+	Word     string
+	Mode     string
+	Field    string
+	Start    int
+	End      int
+	Fragment string
+}
+
+func (v *BlacklistViolation) Error() string {
+	subject := "nazwa produktu"
+	if v.Field == "Description" {
+		subject = "opis produktu"
+	}
+	return fmt.Sprintf("%s zawiera zabronione słowo: %s (reguła %d, tryb %s, pozycja %d-%d, fragment %q)",
+		subject, v.Word, v.WordID, v.Mode, v.Start, v.End, v.Fragment)
+}
+
+// blacklistFields - Pola produktu sprawdzane względem blacklisty
+var blacklistFields = map[string]string{
+	"Name":        models.FieldsName,
+	"Description": models.FieldsDescription,
+}
+
+// Zamiany znaków stosowane przed porównaniem w trybie normalized
+var normalizedRunes = map[rune]rune{
+	'ą': 'a', 'ć': 'c', 'ę': 'e', 'ł': 'l', 'ń': 'n', 'ó': 'o', 'ś': 's', 'ź': 'z', 'ż': 'z',
+	'0': 'o', '1': 'i', '!': 'i', '|': 'i', '3': 'e', '4': 'a', '@': 'a',
+	'5': 's', '$': 's', '7': 't', '8': 'b', '9': 'g',
+}
+
+// ValidateBlacklistWord - Sprawdzenie reguły przed zapisem; pusty tryb oznacza substring, puste pola - wszystkie
+func ValidateBlacklistWord(word *models.BlacklistWord) error {
+	if strings.TrimSpace(word.Word) == "" {
+		return errors.New("Pole 'Word' jest wymagane")
+	}
+
+	switch word.Fields {
+	case "":
+		word.Fields = models.FieldsAll
+	case models.FieldsAll, models.FieldsName, models.FieldsDescription:
+	default:
+		return fmt.Errorf("nieznany zakres pól %q, dozwolone: %s, %s, %s", word.Fields,
+			models.FieldsAll, models.FieldsName, models.FieldsDescription)
+	}
+
+	// Lista kategorii bez pustych pozycji i zbędnych spacji
+	var categories []string
+	for _, category := range strings.Split(word.Categories, ",") {
+		if category = strings.TrimSpace(category); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	word.Categories = strings.Join(categories, ",")
+
+	switch word.MatchMode {
+	case "":
+		word.MatchMode = models.MatchSubstring
+	case models.MatchSubstring, models.MatchWord:
+	case models.MatchRegex:
+		re, err := compileBlacklistRegex(word.Word)
+		if err != nil {
+			return fmt.Errorf("nieprawidłowe wyrażenie regularne: %v", err)
+		}
+		if re.MatchString("") {
+			return errors.New("wyrażenie regularne nie może pasować do pustego tekstu")
+		}
+	case models.MatchNormalized:
+		if len(normalizeForMatch(word.Word).runes) == 0 {
+			return fmt.Errorf("słowo %q po normalizacji jest puste", word.Word)
+		}
+	default:
+		return fmt.Errorf("nieznany tryb dopasowania %q, dozwolone: %s, %s, %s, %s", word.MatchMode,
+			models.MatchSubstring, models.MatchWord, models.MatchRegex, models.MatchNormalized)
+	}
+	return nil
+}
+
+// appliesTo - Czy reguła dotyczy danego pola produktu w danej kategorii
+func appliesTo(word models.BlacklistWord, field, category string) bool {
+	if word.Fields != "" && word.Fields != models.FieldsAll && word.Fields != blacklistFields[field] {
+		return false
+	}
+	if word.Categories == "" {
+		return true
+	}
+	for _, c := range strings.Split(word.Categories, ",") {
+		if strings.EqualFold(strings.TrimSpace(c), category) {
+			return true
+		}
+	}
+	return false
+}
+
+func compileBlacklistRegex(pattern string) (*regexp.Regexp, error) {
+	return regexp.Compile("(?i)" + pattern)
+}
+
+func foldRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// isWordBoundary - Granicą słowa jest znak niealfanumeryczny, przejście camelCase albo litera/cyfra
+func isWordBoundary(runes []rune, i int) bool {
+	if i == 0 || i == len(runes) {
+		return true
+	}
+	prev, next := runes[i-1], runes[i]
+	if !isAlnum(prev) || !isAlnum(next) {
+		return true
+	}
+	if unicode.IsLower(prev) && unicode.IsUpper(next) {
+		return true
+	}
+	return unicode.IsDigit(prev) != unicode.IsDigit(next)
+}
+
+func isAlnum(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// normalizedText - Tekst po normalizacji wraz z pozycją każdego znaku w tekście źródłowym
+type normalizedText struct {
+	runes     []rune
+	positions []int
+}
+
+// normalizeForMatch - Małe litery, polskie znaki i leetspeak na litery, bez separatorów i powtórzeń
+func normalizeForMatch(s string) normalizedText {
+	var n normalizedText
+	for i, r := range []rune(s) {
+		r = normalizeRune(r)
+		if !isAlnum(r) {
+			continue
+		}
+		if len(n.runes) > 0 && n.runes[len(n.runes)-1] == r {
+			continue
+		}
+		n.runes = append(n.runes, r)
+		n.positions = append(n.positions, i)
+	}
+	return n
+}
+
+func normalizeRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if mapped, ok := normalizedRunes[r]; ok {
+		return mapped
+	}
+	return r
+}
+
+// span - Pozycje fragmentu [start, end) tekstu znormalizowanego w tekście źródłowym;
+// fragment obejmuje pominięte powtórzenia ostatniego znaku, ale nie separatory za nim
+func (n normalizedText) span(original []rune, start, end int) (int, int) {
+	endPos := len(original)
+	if end < len(n.positions) {
+		endPos = n.positions[end]
+	}
+	for endPos > n.positions[end-1]+1 && !isAlnum(normalizeRune(original[endPos-1])) {
+		endPos--
+	}
+	return n.positions[start], endPos
+}
+
+// blacklistMatcher - Skompilowana blacklista: automaty dla trybów substring/word i normalized oraz jedno wyrażenie dla regex
+type blacklistMatcher struct {
+	words           []models.BlacklistWord
+	folded          *ahoCorasick
+	foldedWords     []int // wzorzec automatu -> indeks w words
+	normalized      *ahoCorasick
+	normalizedWords []int
+	regex           *regexp.Regexp
+	regexWords      map[int]int // numer grupy -> indeks w words
+}
+
+func newBlacklistMatcher(words []models.BlacklistWord) (*blacklistMatcher, error) {
+	m := &blacklistMatcher{words: words, regexWords: map[int]int{}}
+
+	var folded, normalized [][]rune
+	var alternatives []string
+	groups := map[string]int{}
+	for i, word := range words {
+		switch word.MatchMode {
+		case models.MatchRegex:
+			// Każde wyrażenie kompilujemy osobno, żeby wskazać błędną regułę
+			if _, err := compileBlacklistRegex(word.Word); err != nil {
+				return nil, fmt.Errorf("nieprawidłowe wyrażenie regularne na blackliście (ID %d): %v", word.ID, err)
+			}
+			group := fmt.Sprintf("blacklist_%d", i)
+			groups[group] = i
+			alternatives = append(alternatives, fmt.Sprintf("(?P<%s>(?i:%s))", group, word.Word))
+		case models.MatchNormalized:
+			normalized = append(normalized, normalizeForMatch(word.Word).runes)
+			m.normalizedWords = append(m.normalizedWords, i)
+		default:
+			folded = append(folded, foldRunes(word.Word))
+			m.foldedWords = append(m.foldedWords, i)
+		}
+	}
+
+	m.folded = newAhoCorasick(folded)
+	m.normalized = newAhoCorasick(normalized)
+
+	if len(alternatives) > 0 {
+		re, err := regexp.Compile(strings.Join(alternatives, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("nie można połączyć wyrażeń regularnych z blacklisty: %v", err)
+		}
+		for group, name := range re.SubexpNames() {
+			if index, ok := groups[name]; ok {
+				m.regexWords[group] = index
+			}
+		}
+		m.regex = re
+	}
+
+	return m, nil
+}
+
+// match - Naruszenie zaczynające się najwcześniej w tekście pola (przy remisie reguła o niższym ID) albo nil
+func (m *blacklistMatcher) match(field, text string) *BlacklistViolation {
+	original := []rune(text)
+
+	var best *BlacklistViolation
+	consider := func(index, start, end int) {
+		word := m.words[index]
+		if best != nil && (start > best.Start || start == best.Start && word.ID >= best.WordID) {
+			return
+		}
+		mode := word.MatchMode
+		if mode == "" {
+			mode = models.MatchSubstring
+		}
+		best = &BlacklistViolation{
+			WordID:   word.ID,
+			Word:     word.Word,
+			Mode:     mode,
+			Field:    field,
+			Start:    start,
+			End:      end,
+			Fragment: string(original[start:end]),
+		}
+	}
+
+	m.folded.search(foldRunes(text), func(pattern, start, end int) {
+		index := m.foldedWords[pattern]
+		if m.words[index].MatchMode == models.MatchWord && !(isWordBoundary(original, start) && isWordBoundary(original, end)) {
+			return
+		}
+		consider(index, start, end)
+	})
+
+	normalized := normalizeForMatch(text)
+	m.normalized.search(normalized.runes, func(pattern, start, end int) {
+		start, end = normalized.span(original, start, end)
+		consider(m.normalizedWords[pattern], start, end)
+	})
+
+	if m.regex != nil {
+		// Alternatywa zwraca najwcześniejsze dopasowanie; grupa wskazuje regułę
+		if loc := m.regex.FindStringSubmatchIndex(text); loc != nil && loc[1] > loc[0] {
+			for group, index := range m.regexWords {
+				if loc[2*group] >= 0 {
+					consider(index, utf8.RuneCountInString(text[:loc[0]]), utf8.RuneCountInString(text[:loc[1]]))
+				}
+			}
+		}
+	}
+
+	return best
+}
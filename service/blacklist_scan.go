@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"product-controller/models"
+	"product-controller/repository"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Akcje wykonywane na produktach naruszających blacklistę
+const (
+	ScanActionReport    = "report"    // tylko raport
+	ScanActionFlag      = "flag"      // status flagged, produkt pozostaje widoczny
+	ScanActionUnpublish = "unpublish" // status unpublished, produkt znika z listy
+)
+
+// Stany zadania skanowania
+const (
+	ScanRunning   = "running"
+	ScanCompleted = "completed"
+	ScanFailed    = "failed"
+)
+
+const (
+	scanBatchSize   = 500
+	scanJobsToKeep  = 100
+	scanRetryOnEdit = 3
+)
+
+var ErrScanJobNotFound = errors.New("zadanie skanowania nie istnieje")
+
+// ScanViolation - Produkt naruszający blacklistę
+type ScanViolation struct {
+	ProductID   uint
+	ProductName string
+	BlacklistViolation
+}
+
+// ScanJob - Zadanie skanowania istniejących produktów
+type ScanJob struct {
+	ID         string
+	Trigger    string // manual albo word:<ID>
+	Action     string
+	Status     string
+	Total      int64
+	Scanned    int
+	Updated    int // produkty, którym zmieniono status
+	Violations []ScanViolation
+	Error      string `json:",omitempty"`
+	StartedAt  time.Time
+	FinishedAt *time.Time `json:",omitempty"`
+}
+
+// BlacklistScanService - Skanowanie istniejących produktów względem aktualnej blacklisty. Zadania są trzymane
+// tylko w pamięci procesu (najwyżej scanJobsToKeep ostatnich), więc ich stan znika po restarcie i nie jest
+// widoczny z innych instancji; trwałe są wyłącznie zmiany statusów produktów
+type BlacklistScanService struct {
+	ProductService *ProductService
+
+	mu   sync.Mutex
+	jobs map[string]*ScanJob
+	ids  []string // kolejność utworzenia, do usuwania najstarszych zadań
+}
+
+func NewBlacklistScanService(productService *ProductService) *BlacklistScanService {
+	return &BlacklistScanService{
+		ProductService: productService,
+		jobs:           map[string]*ScanJob{},
+	}
+}
+
+// StartScan - Uruchamia skanowanie w tle; kontekst służy tylko do przeniesienia danych audytowych
+func (s *BlacklistScanService) StartScan(ctx context.Context, action, trigger string) (*ScanJob, error) {
+	action, err := ParseScanAction(action)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newScanJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &ScanJob{
+		ID:        id,
+		Trigger:   trigger,
+		Action:    action,
+		Status:    ScanRunning,
+		StartedAt: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.jobs[id] = job
+	s.ids = append(s.ids, id)
+	s.pruneJobs()
+	snapshot := job.copy()
+	s.mu.Unlock()
+
+	// Zadanie nie może zależeć od czasu życia żądania HTTP
+	audit := AuditInfoFromContext(ctx)
+	if audit.Reason == "" {
+		audit.Reason = "skanowanie blacklisty " + id
+	}
+	go s.run(WithAuditInfo(context.Background(), audit), job)
+
+	return snapshot, nil
+}
+
+// ParseScanAction - Sprawdzenie akcji skanowania; pusta oznacza report
+func ParseScanAction(action string) (string, error) {
+	switch action {
+	case "":
+		return ScanActionReport, nil
+	case ScanActionReport, ScanActionFlag, ScanActionUnpublish:
+		return action, nil
+	}
+	return "", fmt.Errorf("nieznana akcja %q, dozwolone: %s, %s, %s", action,
+		ScanActionReport, ScanActionFlag, ScanActionUnpublish)
+}
+
+// GetScanJob - Aktualny stan zadania
+func (s *BlacklistScanService) GetScanJob(id string) (*ScanJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrScanJobNotFound
+	}
+	return job.copy(), nil
+}
+
+func (s *BlacklistScanService) run(ctx context.Context, job *ScanJob) {
+	err := s.scan(ctx, job)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = ScanCompleted
+	if err != nil {
+		job.Status = ScanFailed
+		job.Error = err.Error()
+	}
+}
+
+func (s *BlacklistScanService) scan(ctx context.Context, job *ScanJob) error {
+	products := s.ProductService.ProductRepo
+
+	total, err := products.CountProducts(repository.ProductFilter{})
+	if err != nil {
+		return err
+	}
+	s.update(func() { job.Total = total })
+
+	keyset := &repository.Keyset{Column: "id"}
+	for {
+		batch, err := products.FindProducts(repository.ProductQuery{Limit: scanBatchSize, Keyset: keyset})
+		if err != nil {
+			return err
+		}
+
+		for i := range batch {
+			violation, updated, err := s.scanProduct(ctx, &batch[i], job.Action)
+			if err != nil {
+				return fmt.Errorf("produkt %d: %w", batch[i].ID, err)
+			}
+
+			s.update(func() {
+				job.Scanned++
+				if violation != nil {
+					job.Violations = append(job.Violations, *violation)
+				}
+				if updated {
+					job.Updated++
+				}
+			})
+		}
+
+		if len(batch) < scanBatchSize {
+			return nil
+		}
+		keyset = &repository.Keyset{Column: "id", ID: batch[len(batch)-1].ID}
+	}
+}
+
+// scanProduct - Sprawdza produkt i w razie naruszenia wykonuje akcję; produkt zmieniony w międzyczasie jest sprawdzany ponownie
+func (s *BlacklistScanService) scanProduct(ctx context.Context, product *models.Product, action string) (*ScanViolation, bool, error) {
+	for attempt := 0; ; attempt++ {
+		err := s.ProductService.checkBlacklist(product)
+		if err == nil {
+			return nil, false, nil
+		}
+		var blacklisted *BlacklistViolation
+		if !errors.As(err, &blacklisted) {
+			return nil, false, err
+		}
+		violation := &ScanViolation{ProductID: product.ID, ProductName: product.Name, BlacklistViolation: *blacklisted}
+
+		// Produktem w kwarantannie zajmuje się moderator; skanowanie nie nadpisuje jego decyzji ani notatki
+		if product.Quarantined() {
+			return violation, false, nil
+		}
+
+		status := scanActionStatus(product.Status, action)
+		if status == product.Status {
+			return violation, false, nil
+		}
+
+		// Notatka pokazuje moderatorowi, które słowo i w którym miejscu zostało dopasowane
+		err = s.ProductService.setProductStatus(ctx, product, status, blacklisted.Error())
+		if err == nil {
+			return violation, true, nil
+		}
+		if !errors.Is(err, repository.ErrVersionConflict) || attempt == scanRetryOnEdit {
+			return nil, false, err
+		}
+
+		product, err = s.ProductService.ProductRepo.GetProductByID(product.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Produkt usunięty w trakcie skanowania nie jest już widoczny
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+}
+
+// scanActionStatus - Status po wykonaniu akcji; produktu ukrytego nie przywracamy do stanu flagged
+func scanActionStatus(status, action string) string {
+	switch action {
+	case ScanActionFlag:
+		if status == models.StatusActive {
+			return models.StatusFlagged
+		}
+	case ScanActionUnpublish:
+		return models.StatusUnpublished
+	}
+	return status
+}
+
+// update - Zmiana stanu zadania pod blokadą, żeby odczyty przez API widziały spójny stan
+func (s *BlacklistScanService) update(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn()
+}
+
+// pruneJobs - Usuwa najstarsze zakończone zadania ponad limit; wywoływane pod blokadą
+func (s *BlacklistScanService) pruneJobs() {
+	for i := 0; len(s.jobs) > scanJobsToKeep && i < len(s.ids); {
+		id := s.ids[i]
+		if s.jobs[id].Status == ScanRunning {
+			i++
+			continue
+		}
+		delete(s.jobs, id)
+		s.ids = append(s.ids[:i], s.ids[i+1:]...)
+	}
+}
+
+func (j *ScanJob) copy() *ScanJob {
+	c := *j
+	c.Violations = append([]ScanViolation(nil), j.Violations...)
+	return &c
+}
+
+func newScanJobID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
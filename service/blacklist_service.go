@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-controller/models"
+	"product-controller/repository"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrBlacklistWordExists     = errors.New("słowo już znajduje się na blackliście")
+	ErrBlacklistWordNotFound   = errors.New("słowo nie istnieje na blackliście")
+	ErrBlacklistWordNotDeleted = errors.New("słowo nie zostało usunięte")
+)
+
+// BlacklistService - Blacklista z matcherami skompilowanymi w pamięci; zmiany przez ten serwis przebudowują matchery.
+// Zmiany wprowadzone bezpośrednio w bazie (np. przez inną instancję) nie są widoczne do czasu kolejnej zmiany.
+type BlacklistService struct {
+	BlacklistRepo *repository.BlacklistRepository
+
+	mu         sync.RWMutex
+	rules      *blacklistRules
+	generation uint64
+}
+
+func NewBlacklistService(blacklistRepo *repository.BlacklistRepository) *BlacklistService {
+	return &BlacklistService{
+		BlacklistRepo: blacklistRepo,
+	}
+}
+
+func (s *BlacklistService) GetAllBlacklistWords() ([]models.BlacklistWord, error) {
+	return s.BlacklistRepo.GetAllBlacklistWords()
+}
+
+func (s *BlacklistService) AddBlacklistWord(ctx context.Context, word *models.BlacklistWord) error {
+	if err := s.addBlacklistWord(ctx, word); err != nil {
+		return err
+	}
+
+	s.rebuild()
+	return nil
+}
+
+// addBlacklistWord - Walidacja i zapis słowa wraz z wpisem historii, bez przebudowy matcherów
+func (s *BlacklistService) addBlacklistWord(ctx context.Context, word *models.BlacklistWord) error {
+	word.ID = 0
+	word.DeletedAt = gorm.DeletedAt{}
+	if err := ValidateBlacklistWord(word); err != nil {
+		return err
+	}
+
+	if existing, _ := s.BlacklistRepo.GetBlacklistWordByWord(word.Word); existing != nil {
+		return existingWordError(existing)
+	}
+
+	err := s.BlacklistRepo.Transaction(func(repo *repository.BlacklistRepository) error {
+		if err := repo.AddBlacklistWord(word); err != nil {
+			return err
+		}
+		return saveBlacklistHistory(ctx, repo, models.BlacklistAdded, word)
+	})
+	if err != nil {
+		// Równoległe dodanie tego samego słowa kończy się naruszeniem unikalnego indeksu
+		if existing, _ := s.BlacklistRepo.GetBlacklistWordByWord(word.Word); existing != nil {
+			return existingWordError(existing)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// existingWordError - Słowo usunięte wciąż zajmuje unikalną nazwę, więc trzeba je przywrócić zamiast dodawać
+func existingWordError(existing *models.BlacklistWord) error {
+	if existing.DeletedAt.Valid {
+		return fmt.Errorf("%w: słowo zostało usunięte (ID %d), przywróć je", ErrBlacklistWordExists, existing.ID)
+	}
+	return ErrBlacklistWordExists
+}
+
+func (s *BlacklistService) DeleteBlacklistWord(ctx context.Context, id uint) error {
+	word, err := s.BlacklistRepo.GetBlacklistWordByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrBlacklistWordNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	err = s.BlacklistRepo.Transaction(func(repo *repository.BlacklistRepository) error {
+		if err := repo.DeleteBlacklistWord(id); err != nil {
+			return err
+		}
+		return saveBlacklistHistory(ctx, repo, models.BlacklistDeleted, word)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.rebuild()
+	return nil
+}
+
+// RestoreBlacklistWord - Przywraca usunięte słowo
+func (s *BlacklistService) RestoreBlacklistWord(ctx context.Context, id uint) (*models.BlacklistWord, error) {
+	word, err := s.BlacklistRepo.GetDeletedBlacklistWordByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBlacklistWordNotDeleted
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.BlacklistRepo.Transaction(func(repo *repository.BlacklistRepository) error {
+		if err := repo.RestoreBlacklistWord(word); err != nil {
+			return err
+		}
+		return saveBlacklistHistory(ctx, repo, models.BlacklistRestored, word)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.rebuild()
+	return word, nil
+}
+
+// GetBlacklistHistory - Zmiany blacklisty od najnowszej
+func (s *BlacklistService) GetBlacklistHistory(filter repository.BlacklistHistoryFilter, limit int) ([]models.BlacklistHistory, error) {
+	return s.BlacklistRepo.FindBlacklistHistory(filter, limit)
+}
+
+func saveBlacklistHistory(ctx context.Context, repo *repository.BlacklistRepository, action string, word *models.BlacklistWord) error {
+	return repo.SaveBlacklistHistory(&models.BlacklistHistory{
+		WordID:     word.ID,
+		Action:     action,
+		Word:       word.Word,
+		MatchMode:  word.MatchMode,
+		Fields:     word.Fields,
+		Categories: word.Categories,
+		AuditInfo:  AuditInfoFromContext(ctx),
+	})
+}
+
+// Check - Pierwsze naruszenie blacklisty w polu produktu danej kategorii albo nil;
+// jedno przejście po tekście niezależnie od rozmiaru blacklisty
+func (s *BlacklistService) Check(field, category, text string) (*BlacklistViolation, error) {
+	rules, err := s.currentRules()
+	if err != nil {
+		return nil, err
+	}
+
+	matcher, err := rules.matcher(blacklistScope{field: field, category: strings.ToLower(category)})
+	if err != nil {
+		return nil, err
+	}
+	return matcher.match(field, text), nil
+}
+
+// currentRules - Zwraca reguły, wczytując je przy pierwszym użyciu albo po unieważnieniu
+func (s *BlacklistService) currentRules() (*blacklistRules, error) {
+	s.mu.RLock()
+	rules, generation := s.rules, s.generation
+	s.mu.RUnlock()
+	if rules != nil {
+		return rules, nil
+	}
+
+	words, err := s.BlacklistRepo.GetAllBlacklistWords()
+	if err != nil {
+		return nil, err
+	}
+	rules = &blacklistRules{words: words, matchers: map[blacklistScope]*blacklistMatcher{}}
+
+	// Reguły wczytane ze starszej listy nie mogą nadpisać nowszych
+	s.mu.Lock()
+	if s.generation == generation {
+		s.rules = rules
+	}
+	s.mu.Unlock()
+
+	return rules, nil
+}
+
+// rebuild - Unieważnia reguły po zmianie blacklisty i od razu wczytuje nowe
+func (s *BlacklistService) rebuild() {
+	s.mu.Lock()
+	s.rules = nil
+	s.generation++
+	s.mu.Unlock()
+
+	// Błąd nie przerywa zmiany; reguły zostaną wczytane przy następnym sprawdzeniu
+	s.currentRules()
+}
+
+// blacklistScope - Pole produktu i kategoria (małymi literami), dla których budowany jest matcher
+type blacklistScope struct {
+	field    string
+	category string
+}
+
+// blacklistRules - Reguły wczytane z bazy oraz matchery zbudowane dla kolejnych zakresów
+type blacklistRules struct {
+	words []models.BlacklistWord
+
+	mu       sync.Mutex
+	matchers map[blacklistScope]*blacklistMatcher
+}
+
+func (r *blacklistRules) matcher(scope blacklistScope) (*blacklistMatcher, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if matcher, ok := r.matchers[scope]; ok {
+		return matcher, nil
+	}
+
+	var words []models.BlacklistWord
+	for _, word := range r.words {
+		if appliesTo(word, scope.field, scope.category) {
+			words = append(words, word)
+		}
+	}
+
+	matcher, err := newBlacklistMatcher(words)
+	if err != nil {
+		return nil, err
+	}
+	r.matchers[scope] = matcher
+	return matcher, nil
+}
+
+// ImportBlacklistWords - Dodaje słowa wiersz po wierszu; błędny albo powtórzony wiersz nie przerywa importu
+func (s *BlacklistService) ImportBlacklistWords(ctx context.Context, rows []BlacklistImportRow) (*BlacklistImportReport, error) {
+	report := &BlacklistImportReport{Results: make([]BlacklistImportResult, 0, len(rows))}
+	defer func() {
+		if report.Created > 0 {
+			s.rebuild()
+		}
+	}()
+
+	for _, row := range rows {
+		word := row.Word
+		result := BlacklistImportResult{Line: row.Line, Word: word.Word}
+
+		if row.Error == "" {
+			if err := ValidateBlacklistWord(&word); err != nil {
+				row.Error = err.Error()
+			}
+		}
+
+		if row.Error != "" {
+			result.Status = ImportInvalid
+			result.Error = row.Error
+			report.Invalid++
+		} else if err := s.addBlacklistWord(ctx, &word); errors.Is(err, ErrBlacklistWordExists) {
+			result.Status = ImportDuplicate
+			result.Error = err.Error()
+			report.Duplicates++
+		} else if err != nil {
+			// Błąd bazy przerywa import; słowa dodane wcześniej pozostają
+			return report, fmt.Errorf("wiersz %d: %w", row.Line, err)
+		} else {
+			result.Status = ImportCreated
+			result.ID = word.ID
+			report.Created++
+		}
+
+		report.Results = append(report.Results, result)
+	}
+
+	return report, nil
+}
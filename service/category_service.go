@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"product-controller/models"
+	"product-controller/repository"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryExists = errors.New("kategoria o tej nazwie już istnieje")
+	ErrCategoryInUse  = errors.New("kategoria jest przypisana do produktów")
+)
+
+type CategoryService struct {
+	CategoryRepo *repository.CategoryRepository
+}
+
+func NewCategoryService(categoryRepo *repository.CategoryRepository) *CategoryService {
+	return &CategoryService{
+		CategoryRepo: categoryRepo,
+	}
+}
+
+func (s *CategoryService) GetAllCategories() ([]models.Category, error) {
+	return s.CategoryRepo.GetAllCategories()
+}
+
+func (s *CategoryService) GetCategoryByID(id uint) (*models.Category, error) {
+	category, err := s.CategoryRepo.GetCategoryByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("kategoria nie istnieje")
+	}
+	return category, err
+}
+
+func (s *CategoryService) AddCategory(category *models.Category) error {
+	category.ID = 0
+	if err := s.validateCategory(category); err != nil {
+		return err
+	}
+
+	return s.CategoryRepo.CreateCategory(category)
+}
+
+func (s *CategoryService) UpdateCategory(id uint, updatedCategory *models.Category) error {
+	existingCategory, err := s.GetCategoryByID(id)
+	if err != nil {
+		return err
+	}
+
+	updatedCategory.ID = id
+	if err = s.validateCategory(updatedCategory); err != nil {
+		return err
+	}
+
+	// Produkty odwołują się do kategorii po nazwie, więc zmiana nazwy zerwałaby to powiązanie
+	if !strings.EqualFold(existingCategory.Name, updatedCategory.Name) {
+		count, err := s.CategoryRepo.CountProductsInCategory(existingCategory.Name)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+	}
+
+	existingCategory.Name = updatedCategory.Name
+	existingCategory.MinPrice = updatedCategory.MinPrice
+	existingCategory.MaxPrice = updatedCategory.MaxPrice
+
+	if err = s.CategoryRepo.UpdateCategory(existingCategory); err != nil {
+		return err
+	}
+
+	*updatedCategory = *existingCategory
+	return nil
+}
+
+func (s *CategoryService) DeleteCategory(id uint) error {
+	category, err := s.GetCategoryByID(id)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.CategoryRepo.CountProductsInCategory(category.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCategoryInUse
+	}
+
+	return s.CategoryRepo.DeleteCategory(id)
+}
+
+func (s *CategoryService) validateCategory(category *models.Category) error {
+	category.Name = strings.TrimSpace(category.Name)
+	if utf8.RuneCountInString(category.Name) < 2 || utf8.RuneCountInString(category.Name) > 50 {
+		return errors.New("nazwa kategorii musi mieć od 2 do 50 znaków")
+	}
+
+	if category.MinPrice < 0 {
+		return errors.New("cena minimalna nie może być ujemna")
+	}
+	if category.MaxPrice <= 0 || category.MaxPrice < category.MinPrice {
+		return errors.New("cena maksymalna musi być dodatnia i nie mniejsza niż minimalna")
+	}
+
+	existing, _ := s.CategoryRepo.GetCategoryByName(category.Name)
+	if existing != nil && existing.ID != category.ID {
+		return ErrCategoryExists
+	}
+
+	return nil
+}
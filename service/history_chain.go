@@ -0,0 +1,185 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"product-controller/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyChainBatchSize = 1000
+
+// ChainBreak - Pierwsze miejsce, w którym łańcuch historii się nie zgadza
+type ChainBreak struct {
+	ProductID uint
+	HistoryID uint
+	Reason    string
+}
+
+// ChainReport - Wynik weryfikacji łańcucha historii; koniec łańcucha każdego produktu jest porównywany
+// z zapisanym w product_history_heads, więc wykrywane jest też usunięcie najnowszych wpisów
+type ChainReport struct {
+	ProductID uint // 0 - cała tabela
+	Checked   int
+	Legacy    int // wpisy sprzed wprowadzenia łańcucha, bez hasha
+	Valid     bool
+	Break     *ChainBreak
+}
+
+// historyHash - SHA-256 z treści wpisu i hasha poprzedniego wpisu tego samego produktu
+func historyHash(h *models.ProductHistory) string {
+	parts := []string{
+		h.PrevHash,
+		strconv.FormatUint(uint64(h.ProductID), 10),
+		strconv.Quote(h.Field),
+		strconv.Quote(h.OldValue),
+		strconv.Quote(h.NewValue),
+		h.ChangedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		strconv.Quote(h.Actor),
+		strconv.Quote(h.RequestID),
+		strconv.Quote(h.SourceIP),
+		strconv.Quote(h.Reason),
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// chainHistory - Uzupełnia czas, PrevHash i Hash nowego wpisu; wymaga transakcji z zablokowanym wierszem produktu
+func (s *ProductService) chainHistory(history *models.ProductHistory) error {
+	last, err := s.ProductRepo.GetLastProductHistory(history.ProductID)
+	if err != nil {
+		return err
+	}
+	if last != nil {
+		history.PrevHash = last.Hash
+	}
+
+	// Baza przechowuje czas z dokładnością do milisekund, więc z taką samą liczymy hash
+	history.ChangedAt = time.Now().Truncate(time.Millisecond)
+	history.Hash = historyHash(history)
+	return nil
+}
+
+// saveHistoryHead - Przesunięcie końca łańcucha produktu na zapisany właśnie wpis, w tej samej transakcji
+func (s *ProductService) saveHistoryHead(history *models.ProductHistory) error {
+	return s.ProductRepo.SaveProductHistoryHead(&models.ProductHistoryHead{
+		ProductID: history.ProductID,
+		HistoryID: history.ID,
+		Hash:      history.Hash,
+	})
+}
+
+// VerifyHistoryChain - Sprawdza łańcuch jednego produktu albo (productID 0) całej tabeli
+func (s *ProductService) VerifyHistoryChain(productID uint) (*ChainReport, error) {
+	report := &ChainReport{ProductID: productID, Valid: true}
+
+	headList, err := s.ProductRepo.GetProductHistoryHeads(productID)
+	if err != nil {
+		return nil, err
+	}
+	heads := make(map[uint]models.ProductHistoryHead, len(headList))
+	for _, head := range headList {
+		heads[head.ProductID] = head
+	}
+
+	var currentProduct, afterID uint
+	var prevHash string
+	var hashed bool
+
+	// Koniec łańcucha zakończonego produktu musi być jego zapisanym końcem
+	finishProduct := func() bool {
+		if currentProduct == 0 {
+			return true
+		}
+		head, ok := heads[currentProduct]
+		delete(heads, currentProduct)
+		if reason := verifyHistoryHead(head, ok, prevHash); reason != "" {
+			report.Valid = false
+			report.Break = &ChainBreak{ProductID: currentProduct, HistoryID: afterID, Reason: reason}
+			return false
+		}
+		return true
+	}
+
+	for {
+		batch, err := s.ProductRepo.GetHistoryChainBatch(productID, currentProduct, afterID, historyChainBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range batch {
+			h := &batch[i]
+			if h.ProductID != currentProduct {
+				if !finishProduct() {
+					return report, nil
+				}
+				currentProduct, prevHash, hashed = h.ProductID, "", false
+			}
+			afterID = h.ID
+			report.Checked++
+
+			if reason := verifyHistoryEntry(h, prevHash, hashed); reason != "" {
+				if h.Hash == "" && !hashed {
+					report.Legacy++
+					continue
+				}
+				report.Valid = false
+				report.Break = &ChainBreak{ProductID: h.ProductID, HistoryID: h.ID, Reason: reason}
+				return report, nil
+			}
+
+			prevHash, hashed = h.Hash, true
+		}
+
+		if len(batch) < historyChainBatchSize {
+			break
+		}
+	}
+
+	if !finishProduct() {
+		return report, nil
+	}
+
+	// Pozostałe końce należą do produktów, z których historii nie został żaden wpis
+	for _, head := range headList {
+		if _, ok := heads[head.ProductID]; ok {
+			report.Valid = false
+			report.Break = &ChainBreak{ProductID: head.ProductID, HistoryID: head.HistoryID, Reason: "brak wpisów historii, choć zapisano koniec łańcucha"}
+			return report, nil
+		}
+	}
+
+	return report, nil
+}
+
+// verifyHistoryHead - Porównanie hasha ostatniego wpisu produktu z zapisanym końcem łańcucha; produkty wyłącznie
+// z wpisami sprzed łańcucha nie mają końca
+func verifyHistoryHead(head models.ProductHistoryHead, ok bool, lastHash string) string {
+	switch {
+	case !ok && lastHash != "":
+		return "brak zapisanego końca łańcucha"
+	case ok && head.Hash != lastHash:
+		return fmt.Sprintf("ostatni wpis nie jest zapisanym końcem łańcucha (oczekiwano wpisu %d), usunięto najnowsze wpisy", head.HistoryID)
+	}
+	return ""
+}
+
+func verifyHistoryEntry(h *models.ProductHistory, prevHash string, hashed bool) string {
+	if h.Hash == "" {
+		if !hashed {
+			return "brak hasha (wpis sprzed łańcucha)"
+		}
+		return "brak hasha po wpisie objętym łańcuchem"
+	}
+	if h.PrevHash != prevHash {
+		return fmt.Sprintf("PrevHash nie zgadza się z poprzednim wpisem (oczekiwano %q)", prevHash)
+	}
+	if historyHash(h) != h.Hash {
+		return "treść wpisu nie zgadza się z jego hashem"
+	}
+	return ""
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// patchOperation - Pojedyncza operacja JSON Patch (RFC 6902)
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	From  string      `json:"from"`
+	Value interface{} `json:"value"`
+}
+
+// applyMergePatch - JSON Merge Patch (RFC 7396)
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = map[string]interface{}{}
+	}
+
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = applyMergePatch(targetObj[key], value)
+	}
+
+	return targetObj
+}
+
+// applyJSONPatch - JSON Patch (RFC 6902); operacje są wykonywane kolejno i przerywane przy pierwszym błędzie
+func applyJSONPatch(doc interface{}, ops []patchOperation) (interface{}, error) {
+	var err error
+	for i, op := range ops {
+		doc, err = applyPatchOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operacja %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyPatchOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, op.Path, op.Value)
+	case "remove":
+		doc, _, err := pointerRemove(doc, op.Path)
+		return doc, err
+	case "replace":
+		doc, _, err := pointerRemove(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.Path, op.Value)
+	case "move":
+		if op.Path == op.From || strings.HasPrefix(op.Path, op.From+"/") {
+			if op.Path == op.From {
+				return doc, nil
+			}
+			return nil, errors.New("nie można przenieść wartości do jej własnego potomka")
+		}
+		doc, value, err := pointerRemove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.Path, value)
+	case "copy":
+		value, err := pointerGet(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, op.Path, deepCopy(value))
+	case "test":
+		value, err := pointerGet(doc, op.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(value, op.Value) {
+			return nil, errors.New("test nie powiódł się")
+		}
+		return doc, nil
+	}
+
+	return nil, fmt.Errorf("nieznana operacja: %q", op.Op)
+}
+
+// parsePointer - Rozbicie wskaźnika JSON Pointer (RFC 6901) na segmenty
+func parsePointer(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("nieprawidłowa ścieżka: %q", path)
+	}
+
+	parts := strings.Split(path[1:], "/")
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "~1", "/")
+		parts[i] = strings.ReplaceAll(part, "~0", "~")
+	}
+	return parts, nil
+}
+
+func pointerGet(doc interface{}, path string) (interface{}, error) {
+	parts, err := parsePointer(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, part := range parts {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[part]
+			if !ok {
+				return nil, fmt.Errorf("ścieżka %q nie istnieje", path)
+			}
+			current = value
+		case []interface{}:
+			index, err := arrayIndex(part, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("ścieżka %q nie istnieje", path)
+		}
+	}
+	return current, nil
+}
+
+// pointerParent - Zwraca rodzica wskazywanego elementu oraz ostatni segment ścieżki
+func pointerParent(doc interface{}, path string) (interface{}, string, error) {
+	parts, err := parsePointer(path)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(parts) == 0 {
+		return nil, "", nil
+	}
+
+	parentPath := ""
+	for _, part := range parts[:len(parts)-1] {
+		part = strings.ReplaceAll(part, "~", "~0")
+		parentPath += "/" + strings.ReplaceAll(part, "/", "~1")
+	}
+
+	parent, err := pointerGet(doc, parentPath)
+	if err != nil {
+		return nil, "", err
+	}
+	return parent, parts[len(parts)-1], nil
+}
+
+func pointerAdd(doc interface{}, path string, value interface{}) (interface{}, error) {
+	if path == "" {
+		return value, nil
+	}
+
+	parent, key, err := pointerParent(doc, path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		node[key] = value
+		return doc, nil
+	case []interface{}:
+		index, err := arrayIndex(key, len(node), true)
+		if err != nil {
+			return nil, err
+		}
+		node = append(node, nil)
+		copy(node[index+1:], node[index:])
+		node[index] = value
+		return replaceAt(doc, path, node)
+	}
+
+	return nil, fmt.Errorf("ścieżka %q nie istnieje", path)
+}
+
+func pointerRemove(doc interface{}, path string) (interface{}, interface{}, error) {
+	if path == "" {
+		return nil, doc, nil
+	}
+
+	parent, key, err := pointerParent(doc, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]interface{}:
+		value, ok := node[key]
+		if !ok {
+			return nil, nil, fmt.Errorf("ścieżka %q nie istnieje", path)
+		}
+		delete(node, key)
+		return doc, value, nil
+	case []interface{}:
+		index, err := arrayIndex(key, len(node), false)
+		if err != nil {
+			return nil, nil, err
+		}
+		value := node[index]
+		node = append(node[:index:index], node[index+1:]...)
+		doc, err = replaceAt(doc, path, node)
+		return doc, value, err
+	}
+
+	return nil, nil, fmt.Errorf("ścieżka %q nie istnieje", path)
+}
+
+// replaceAt - Podmienia tablicę-rodzica po zmianie jej długości
+func replaceAt(doc interface{}, path string, array []interface{}) (interface{}, error) {
+	parts, _ := parsePointer(path)
+	parentParts := parts[:len(parts)-1]
+	if len(parentParts) == 0 {
+		return array, nil
+	}
+
+	grandPath := ""
+	for _, part := range parentParts[:len(parentParts)-1] {
+		part = strings.ReplaceAll(part, "~", "~0")
+		grandPath += "/" + strings.ReplaceAll(part, "/", "~1")
+	}
+	grand, err := pointerGet(doc, grandPath)
+	if err != nil {
+		return nil, err
+	}
+
+	key := parentParts[len(parentParts)-1]
+	switch node := grand.(type) {
+	case map[string]interface{}:
+		node[key] = array
+	case []interface{}:
+		index, err := arrayIndex(key, len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		node[index] = array
+	}
+	return doc, nil
+}
+
+func arrayIndex(part string, length int, allowEnd bool) (int, error) {
+	if part == "-" && allowEnd {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(part)
+	if err != nil || index < 0 || (part != "0" && strings.HasPrefix(part, "0")) {
+		return 0, fmt.Errorf("nieprawidłowy indeks tablicy: %q", part)
+	}
+
+	max := length - 1
+	if allowEnd {
+		max = length
+	}
+	if index > max {
+		return 0, fmt.Errorf("indeks tablicy poza zakresem: %d", index)
+	}
+	return index, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return value
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"product-controller/models"
+)
+
+// checkLowStock - Alert, gdy zmiana przeprowadziła produkt ze stanu powyżej progu zamówienia do progu lub poniżej.
+// Wywoływane w transakcji zmiany, więc wycofana zmiana nie zostawia alertu; produkt z otwartym alertem nie dostaje
+// kolejnego, dopóki poprzedni nie zostanie potwierdzony
+func (s *ProductService) checkLowStock(before, after *models.Product) error {
+	if before.LowStock() || !after.LowStock() {
+		return nil
+	}
+
+	open, err := s.ProductRepo.HasOpenLowStockAlert(after.ID)
+	if err != nil || open {
+		return err
+	}
+
+	return s.ProductRepo.CreateLowStockAlert(&models.LowStockAlert{
+		ProductID:       after.ID,
+		ProductName:     after.Name,
+		Quantity:        after.Quantity,
+		ReorderPoint:    after.ReorderPoint,
+		ReorderQuantity: after.ReorderQuantity,
+		Status:          models.AlertOpen,
+	})
+}
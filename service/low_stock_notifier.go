@@ -0,0 +1,75 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"product-controller/models"
+	"time"
+)
+
+// LowStockNotifier - Kanał dostarczania alertów niskiego stanu
+type LowStockNotifier interface {
+	NotifyLowStock(ctx context.Context, alert models.LowStockAlert) error
+}
+
+// LogNotifier - Zapis alertów do logu
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// NewLogNotifier - Notifier piszący do wskazanego loggera; nil oznacza standardowy logger
+func NewLogNotifier(logger *log.Logger) *LogNotifier {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogNotifier{
+		Logger: logger,
+	}
+}
+
+func (n *LogNotifier) NotifyLowStock(ctx context.Context, alert models.LowStockAlert) error {
+	n.Logger.Printf("Niski stan produktu %s (ID %d): %d szt., próg %d, zamów %d szt.",
+		alert.ProductName, alert.ProductID, alert.Quantity, alert.ReorderPoint, alert.ReorderQuantity)
+	return nil
+}
+
+// WebhookNotifier - Wysyłka alertu jako JSON metodą POST na wskazany adres
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(ctx context.Context, alert models.LowStockAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook odpowiedział statusem %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"product-controller/models"
+	"product-controller/repository"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formaty eksportu produktów
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportXLSX   = "xlsx"
+)
+
+const exportBatchSize = 1000
+
+// Kolumny eksportu w kolejności zapisu
+var exportColumns = []string{"ID", "Name", "Category", "Description", "Price", "Quantity", "Status", "CreatedAt", "UpdatedAt"}
+
+// ProductExportWriter - Zapis kolejnych produktów w wybranym formacie; Close kończy plik
+type ProductExportWriter interface {
+	Write(product *models.Product) error
+	Close() error
+}
+
+// NewProductExportWriter - Writer dla formatu csv, ndjson albo xlsx
+func NewProductExportWriter(format string, w io.Writer) (ProductExportWriter, error) {
+	switch format {
+	case ExportCSV:
+		writer := csv.NewWriter(w)
+		return &csvExportWriter{writer: writer}, writer.Write(exportColumns)
+	case ExportNDJSON:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w)}, nil
+	case ExportXLSX:
+		return newXLSXExportWriter(w)
+	}
+	return nil, fmt.Errorf("nieznany format %q, dozwolone: %s, %s, %s", format, ExportCSV, ExportNDJSON, ExportXLSX)
+}
+
+// ExportProducts - Przekazuje produkty spełniające filtr partiami po exportBatchSize, w kolejności ID
+func (s *ProductService) ExportProducts(filter repository.ProductFilter, fn func(batch []models.Product) error) error {
+	keyset := &repository.Keyset{Column: "id"}
+	for {
+		batch, err := s.ProductRepo.FindProducts(repository.ProductQuery{Filter: filter, Limit: exportBatchSize, Keyset: keyset})
+		if err != nil {
+			return err
+		}
+		if len(batch) > 0 {
+			if err = fn(batch); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		keyset = &repository.Keyset{Column: "id", ID: batch[len(batch)-1].ID}
+	}
+}
+
+func exportValues(p *models.Product) []string {
+	return []string{
+		strconv.FormatUint(uint64(p.ID), 10),
+		p.Name,
+		p.Category,
+		p.Description,
+		strconv.FormatFloat(p.Price, 'f', 2, 64),
+		strconv.Itoa(p.Quantity),
+		p.Status,
+		p.CreatedAt.Format(time.RFC3339),
+		p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (e *csvExportWriter) Write(product *models.Product) error {
+	values := exportValues(product)
+	// Kolumny tekstowe: Name, Category, Description i Status
+	for _, i := range []int{1, 2, 3, 6} {
+		values[i] = csvSafe(values[i])
+	}
+	return e.writer.Write(values)
+}
+
+// csvSafe - Poprzedza apostrofem tekst, który arkusz kalkulacyjny zinterpretowałby jako formułę
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+func (e *csvExportWriter) Close() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Write(product *models.Product) error {
+	return e.encoder.Encode(product)
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// Stałe części pakietu XLSX; arkusz zapisywany jest strumieniowo jako ostatni plik archiwum
+var xlsxParts = []struct{ name, content string }{
+	{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`},
+	{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`},
+	{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="Produkty" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+	{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`},
+}
+
+// xlsxExportWriter - Minimalny arkusz XLSX: tekst jako inline strings, liczby jako wartości liczbowe
+type xlsxExportWriter struct {
+	archive *zip.Writer
+	sheet   io.Writer
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range xlsxParts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.WriteString(sheet, xml.Header+`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &xlsxExportWriter{archive: archive, sheet: sheet}
+	return e, e.writeRow(exportColumns, nil)
+}
+
+func (e *xlsxExportWriter) Write(product *models.Product) error {
+	// Kolumny ID, Price i Quantity jako liczby
+	return e.writeRow(exportValues(product), map[int]bool{0: true, 4: true, 5: true})
+}
+
+func (e *xlsxExportWriter) writeRow(values []string, numeric map[int]bool) error {
+	if _, err := io.WriteString(e.sheet, "<row>"); err != nil {
+		return err
+	}
+	for i, value := range values {
+		var err error
+		if numeric[i] {
+			_, err = fmt.Fprintf(e.sheet, `<c t="n"><v>%s</v></c>`, value)
+		} else {
+			if _, err = io.WriteString(e.sheet, `<c t="inlineStr"><is><t xml:space="preserve">`); err == nil {
+				if err = xml.EscapeText(e.sheet, []byte(value)); err == nil {
+					_, err = io.WriteString(e.sheet, `</t></is></c>`)
+				}
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(e.sheet, "</row>")
+	return err
+}
+
+func (e *xlsxExportWriter) Close() error {
+	if _, err := io.WriteString(e.sheet, `</sheetData></worksheet>`); err != nil {
+		return err
+	}
+	return e.archive.Close()
+}
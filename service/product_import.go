@@ -0,0 +1,357 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"product-controller/models"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Tryby importu produktów
+const (
+	ImportBestEffort = "best-effort" // każdy wiersz zapisywany niezależnie
+	ImportAtomic     = "atomic"      // wszystkie wiersze albo żaden
+	ImportDryRun     = "dry-run"     // pełna walidacja i zapis w transakcji, która jest zawsze wycofywana
+)
+
+// Formaty importu produktów
+const (
+	ProductFormatCSV    = "csv"    // nagłówek z nazwami pól: Name, Category, Description, Price, Quantity
+	ProductFormatNDJSON = "ndjson" // jeden obiekt JSON w linii
+)
+
+// Wynik importu pojedynczego wiersza
+const (
+	ProductImportCreated = "created"
+	ProductImportUpdated = "updated"
+	ProductImportSkipped = "skipped" // produkt o tej nazwie istnieje i nie wymaga zmian
+	ProductImportTrashed = "trashed" // produkt o tej nazwie jest w koszu; wiersz liczony jako nieudany
+	ProductImportFailed  = "failed"
+)
+
+// errImportRollback - Wycofanie transakcji importu bez błędu po stronie bazy
+var errImportRollback = errors.New("import wycofany")
+
+// ProductImportRow - Produkt odczytany z pliku; Fields to pola obecne w wierszu
+type ProductImportRow struct {
+	Line    int
+	Product models.Product
+	Fields  map[string]bool
+	Error   string // błąd odczytu wiersza
+}
+
+// ProductImportResult - Wynik importu jednego wiersza
+type ProductImportResult struct {
+	Line      int
+	Name      string
+	Status    string
+	ProductID uint   `json:",omitempty"`
+	Error     string `json:",omitempty"`
+}
+
+// ProductImportReport - Podsumowanie importu; Committed mówi, czy zmiany zostały zapisane
+type ProductImportReport struct {
+	Mode      string
+	Committed bool
+	Created   int
+	Updated   int
+	Skipped   int
+	Failed    int
+	Rows      []ProductImportResult
+}
+
+// ParseImportMode - Sprawdzenie trybu importu; pusty oznacza atomic
+func ParseImportMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return ImportAtomic, nil
+	case ImportBestEffort, ImportAtomic, ImportDryRun:
+		return mode, nil
+	}
+	return "", fmt.Errorf("nieznany tryb importu %q, dozwolone: %s, %s, %s", mode, ImportBestEffort, ImportAtomic, ImportDryRun)
+}
+
+// importFieldNames - Nazwy kolumn i kluczy JSON (bez rozróżniania wielkości liter) na pola produktu
+var importFieldNames = map[string]string{
+	"name":        "Name",
+	"category":    "Category",
+	"description": "Description",
+	"price":       "Price",
+	"quantity":    "Quantity",
+
+	"reorderpoint":     "ReorderPoint",
+	"reorder_point":    "ReorderPoint",
+	"reorderquantity":  "ReorderQuantity",
+	"reorder_quantity": "ReorderQuantity",
+}
+
+func importField(name string) (string, error) {
+	field, ok := importFieldNames[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return "", fmt.Errorf("pole %s nie istnieje lub nie może być importowane", name)
+	}
+	return field, nil
+}
+
+// ReadProductImport - Odczyt produktów z pliku CSV albo NDJSON
+func ReadProductImport(format string, r io.Reader) ([]ProductImportRow, error) {
+	switch format {
+	case ProductFormatCSV:
+		return readProductCSV(r)
+	case ProductFormatNDJSON:
+		return readProductNDJSON(r)
+	}
+	return nil, fmt.Errorf("nieznany format %q, dozwolone: %s, %s", format, ProductFormatCSV, ProductFormatNDJSON)
+}
+
+func readProductCSV(r io.Reader) ([]ProductImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("plik CSV jest pusty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("niepoprawny plik CSV: %w", err)
+	}
+
+	columns := make([]string, len(header))
+	for i, name := range header {
+		if columns[i], err = importField(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []ProductImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("niepoprawny plik CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := ProductImportRow{Line: line, Fields: map[string]bool{}}
+		for i, value := range record {
+			if err := setImportField(&row.Product, columns[i], strings.TrimSpace(value)); err != nil && row.Error == "" {
+				row.Error = err.Error()
+			}
+			row.Fields[columns[i]] = true
+		}
+		rows = append(rows, row)
+	}
+}
+
+func setImportField(product *models.Product, field, value string) error {
+	var err error
+	switch field {
+	case "Name":
+		product.Name = value
+	case "Category":
+		product.Category = value
+	case "Description":
+		product.Description = value
+	case "Price":
+		if product.Price, err = strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("niepoprawna cena: %q", value)
+		}
+	case "Quantity":
+		if product.Quantity, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("niepoprawna ilość: %q", value)
+		}
+	case "ReorderPoint":
+		if product.ReorderPoint, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("niepoprawny próg zamówienia: %q", value)
+		}
+	case "ReorderQuantity":
+		if product.ReorderQuantity, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("niepoprawna ilość zamówienia: %q", value)
+		}
+	}
+	return nil
+}
+
+func readProductNDJSON(r io.Reader) ([]ProductImportRow, error) {
+	var rows []ProductImportRow
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := ProductImportRow{Line: line, Fields: map[string]bool{}}
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(text), &object); err != nil {
+			row.Error = "niepoprawny obiekt JSON"
+			rows = append(rows, row)
+			continue
+		}
+
+		for key := range object {
+			field, err := importField(key)
+			if err != nil {
+				row.Error = err.Error()
+				break
+			}
+			row.Fields[field] = true
+		}
+		if row.Error == "" {
+			if err := json.Unmarshal([]byte(text), &row.Product); err != nil {
+				row.Error = "niepoprawny typ wartości: " + strings.TrimPrefix(err.Error(), "json: ")
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("błąd odczytu pliku: %w", err)
+	}
+	return rows, nil
+}
+
+// mergeImportField - Przenosi jedno pole między produktami
+func mergeImportField(target, source *models.Product, field string) {
+	switch field {
+	case "Name":
+		target.Name = source.Name
+	case "Category":
+		target.Category = source.Category
+	case "Description":
+		target.Description = source.Description
+	case "Price":
+		target.Price = source.Price
+	case "Quantity":
+		target.Quantity = source.Quantity
+	case "ReorderPoint":
+		target.ReorderPoint = source.ReorderPoint
+	case "ReorderQuantity":
+		target.ReorderQuantity = source.ReorderQuantity
+	}
+}
+
+// ImportProducts - Import produktów z aktualizacją istniejących po nazwie
+func (s *ProductService) ImportProducts(ctx context.Context, rows []ProductImportRow, mode string) (*ProductImportReport, error) {
+	if AuditInfoFromContext(ctx).Reason == "" {
+		ctx = WithReason(ctx, "import produktów")
+	}
+
+	report := &ProductImportReport{Mode: mode, Rows: make([]ProductImportResult, 0, len(rows))}
+
+	if mode == ImportBestEffort {
+		for _, row := range rows {
+			if err := s.importRow(ctx, row, report); err != nil {
+				return report, err
+			}
+		}
+		report.Committed = true
+		return report, nil
+	}
+
+	// Wiersze w zagnieżdżonych transakcjach (savepointach), żeby błąd jednego nie przerywał walidacji pozostałych
+	err := s.inTransaction(func(tx *ProductService) error {
+		for _, row := range rows {
+			if err := tx.importRow(ctx, row, report); err != nil {
+				return err
+			}
+		}
+		if mode == ImportDryRun || report.Failed > 0 {
+			return errImportRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRollback) {
+		return report, err
+	}
+
+	report.Committed = err == nil
+	return report, nil
+}
+
+// importRow - Zapis jednego wiersza; błędy walidacji trafiają do raportu, błąd zwracany tylko dla problemów z bazą
+func (s *ProductService) importRow(ctx context.Context, row ProductImportRow, report *ProductImportReport) error {
+	result := ProductImportResult{Line: row.Line, Name: row.Product.Name}
+	defer func() { report.Rows = append(report.Rows, result) }()
+
+	fail := func(err error) {
+		result.Status = ProductImportFailed
+		result.Error = err.Error()
+		report.Failed++
+	}
+
+	if row.Error != "" {
+		fail(errors.New(row.Error))
+		return nil
+	}
+	if !row.Fields["Name"] {
+		fail(errors.New("brak nazwy produktu"))
+		return nil
+	}
+
+	// Nazwa jest unikalna także wśród produktów w koszu, więc szukamy również tam
+	existing, err := s.ProductRepo.GetProductByNameUnscoped(row.Product.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		product := row.Product
+		if err = s.AddProduct(ctx, &product); err != nil {
+			fail(err)
+			return nil
+		}
+		result.Status = ProductImportCreated
+		result.ProductID = product.ID
+		report.Created++
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing.DeletedAt.Valid {
+		fail(fmt.Errorf("produkt o tej nazwie znajduje się w koszu (ID %d), przywróć go przed importem albo usuń trwale", existing.ID))
+		result.Status = ProductImportTrashed
+		result.ProductID = existing.ID
+		return nil
+	}
+
+	// Aktualizacja tylko pól obecnych w wierszu
+	updated := *existing
+	for field := range row.Fields {
+		mergeImportField(&updated, &row.Product, field)
+	}
+	result.ProductID = existing.ID
+
+	if err = s.validateProduct(&updated); err != nil {
+		fail(err)
+		return nil
+	}
+	fields, err := s.screenBlacklist(existing, &updated, editableProductFields)
+	if err != nil {
+		fail(err)
+		return nil
+	}
+
+	if updated == *existing {
+		result.Status = ProductImportSkipped
+		report.Skipped++
+		return nil
+	}
+
+	if err = s.applyChanges(ctx, existing, &updated, fields); err != nil {
+		fail(err)
+		return nil
+	}
+	result.Status = ProductImportUpdated
+	report.Updated++
+	return nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-controller/models"
+)
+
+// Reakcja na naruszenie blacklisty przy zapisie produktu
+const (
+	BlacklistModeReject     = "reject"     // zapis jest odrzucany
+	BlacklistModeQuarantine = "quarantine" // produkt zapisuje się ze statusem pending i czeka na moderatora
+)
+
+var (
+	ErrNotAwaitingModeration = errors.New("produkt nie oczekuje na moderację")
+	ErrProductNotSellable    = errors.New("produkt jest ukryty, czeka na moderację albo został odrzucony i nie może być sprzedawany")
+)
+
+// Statusy, z których moderator może zatwierdzić albo odrzucić produkt
+var moderatedStatuses = map[string]bool{
+	models.StatusPending:     true,
+	models.StatusFlagged:     true,
+	models.StatusUnpublished: true,
+}
+
+// ParseBlacklistMode - Sprawdzenie trybu blacklisty; pusty oznacza reject
+func ParseBlacklistMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return BlacklistModeReject, nil
+	case BlacklistModeReject, BlacklistModeQuarantine:
+		return mode, nil
+	}
+	return "", fmt.Errorf("nieznany tryb blacklisty %q, dozwolone: %s, %s", mode, BlacklistModeReject, BlacklistModeQuarantine)
+}
+
+// ApproveProduct - Decyzja moderatora: produkt staje się aktywny
+func (s *ProductService) ApproveProduct(ctx context.Context, id uint, version uint) (*models.Product, error) {
+	return s.moderateProduct(ctx, id, version, models.StatusActive, "moderacja: produkt zatwierdzony")
+}
+
+// RejectProduct - Decyzja moderatora: produkt pozostaje ukryty ze statusem rejected
+func (s *ProductService) RejectProduct(ctx context.Context, id uint, version uint) (*models.Product, error) {
+	return s.moderateProduct(ctx, id, version, models.StatusRejected, "moderacja: produkt odrzucony")
+}
+
+func (s *ProductService) moderateProduct(ctx context.Context, id uint, version uint, status, defaultReason string) (*models.Product, error) {
+	product, err := s.getExistingProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkVersion(product, version); err != nil {
+		return nil, err
+	}
+
+	if !moderatedStatuses[product.Status] {
+		return nil, ErrNotAwaitingModeration
+	}
+
+	if AuditInfoFromContext(ctx).Reason == "" {
+		ctx = WithReason(ctx, defaultReason)
+	}
+
+	// Notatka zostaje jako ślad naruszenia, którego dotyczyła decyzja
+	if err = s.setProductStatus(ctx, product, status, product.ModerationNote); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"product-controller/models"
+	"strings"
+)
+
+// Pola produktu, które klient może zmieniać
+var editableProductFields = map[string]bool{
+	"Name":            true,
+	"Category":        true,
+	"Description":     true,
+	"Price":           true,
+	"Quantity":        true,
+	"ReorderPoint":    true,
+	"ReorderQuantity": true,
+}
+
+// MergePatchProduct - Częściowa aktualizacja produktu w formacie JSON Merge Patch (RFC 7396)
+func (s *ProductService) MergePatchProduct(ctx context.Context, id uint, patch []byte, version uint) (*models.Product, error) {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return nil, invalidf("niepoprawny dokument JSON Merge Patch")
+	}
+
+	patchObj, ok := patchDoc.(map[string]interface{})
+	if !ok {
+		return nil, invalidf("dokument JSON Merge Patch musi być obiektem")
+	}
+
+	fields := make(map[string]bool, len(patchObj))
+	for key := range patchObj {
+		fields[key] = true
+	}
+
+	return s.patchProduct(ctx, id, version, fields, func(doc interface{}) (interface{}, error) {
+		return applyMergePatch(doc, patchDoc), nil
+	})
+}
+
+// JSONPatchProduct - Częściowa aktualizacja produktu w formacie JSON Patch (RFC 6902)
+func (s *ProductService) JSONPatchProduct(ctx context.Context, id uint, patch []byte, version uint) (*models.Product, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, invalidf("niepoprawny dokument JSON Patch")
+	}
+
+	fields := make(map[string]bool)
+	for _, op := range ops {
+		if op.Op == "test" {
+			continue
+		}
+		for _, path := range []string{op.Path, op.From} {
+			parts, err := parsePointer(path)
+			if err != nil {
+				return nil, &ValidationError{Err: err}
+			}
+			if len(parts) > 0 {
+				fields[parts[0]] = true
+			}
+		}
+		if op.Path == "" {
+			return nil, invalidf("nie można zastąpić całego produktu, użyj PUT")
+		}
+	}
+
+	return s.patchProduct(ctx, id, version, fields, func(doc interface{}) (interface{}, error) {
+		return applyJSONPatch(doc, ops)
+	})
+}
+
+func (s *ProductService) patchProduct(ctx context.Context, id uint, version uint, fields map[string]bool, apply func(doc interface{}) (interface{}, error)) (*models.Product, error) {
+	for field := range fields {
+		if !editableProductFields[field] {
+			return nil, invalidf("pole %s nie istnieje lub nie może być modyfikowane", field)
+		}
+	}
+
+	existingProduct, err := s.getExistingProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkVersion(existingProduct, version); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(existingProduct)
+	if err != nil {
+		return nil, err
+	}
+	var doc interface{}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	// Błędy stosowania dokumentu (brak ścieżki, nieudany test) wynikają z treści żądania
+	doc, err = apply(doc)
+	if err != nil {
+		return nil, &ValidationError{Err: err}
+	}
+
+	data, err = json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var patchedProduct models.Product
+	if err = json.Unmarshal(data, &patchedProduct); err != nil {
+		return nil, invalidf("niepoprawny typ wartości: %s", strings.TrimPrefix(err.Error(), "json: "))
+	}
+	patchedProduct.ID = id
+
+	if err = s.validateFields(&patchedProduct, fields); err != nil {
+		return nil, err
+	}
+	if fields, err = s.screenBlacklist(existingProduct, &patchedProduct, fields); err != nil {
+		return nil, err
+	}
+
+	if err = s.applyChanges(ctx, existingProduct, &patchedProduct, fields); err != nil {
+		return nil, err
+	}
+
+	return existingProduct, nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-controller/models"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrProductDidNotExist = errors.New("produkt nie istniał we wskazanym momencie")
+
+// productSnapshot - Stan produktu odtworzony z historii
+type productSnapshot struct {
+	Product models.Product
+	Deleted bool
+	Existed bool
+}
+
+func currentSnapshot(product *models.Product) productSnapshot {
+	return productSnapshot{
+		Product: *product,
+		Deleted: product.DeletedAt.Valid,
+		Existed: true,
+	}
+}
+
+// replayBackwards - Cofa na kopii stanu kolejne zmiany, od najnowszej do najstarszej
+func replayBackwards(state productSnapshot, newer []models.ProductHistory) (*productSnapshot, error) {
+	snapshot := &state
+	p := &snapshot.Product
+
+	for _, h := range newer {
+		var err error
+		switch h.Field {
+		case "Name":
+			p.Name = h.OldValue
+			// Pusta poprzednia nazwa oznacza wpis z utworzenia produktu
+			if h.OldValue == "" {
+				snapshot.Existed = false
+			}
+		case "Category":
+			if h.OldValue != "" {
+				p.Category = h.OldValue
+			}
+		case "Description":
+			p.Description = h.OldValue
+		case "Price":
+			if h.OldValue != "" {
+				p.Price, err = strconv.ParseFloat(h.OldValue, 64)
+			}
+		case "Quantity":
+			if h.OldValue != "" {
+				p.Quantity, err = strconv.Atoi(h.OldValue)
+			}
+		case "ReorderPoint":
+			if h.OldValue != "" {
+				p.ReorderPoint, err = strconv.Atoi(h.OldValue)
+			}
+		case "ReorderQuantity":
+			if h.OldValue != "" {
+				p.ReorderQuantity, err = strconv.Atoi(h.OldValue)
+			}
+		case "Deleted":
+			snapshot.Deleted = h.OldValue == "true"
+		case "Status":
+			p.Status = h.OldValue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("uszkodzony wpis historii %d: %w", h.ID, err)
+		}
+	}
+
+	return snapshot, nil
+}
+
+// snapshotAfterEntry - Stan produktu tuż po zapisaniu wskazanego wpisu historii
+func (s *ProductService) snapshotAfterEntry(product *models.Product, historyID uint) (*productSnapshot, error) {
+	entry, err := s.ProductRepo.GetProductHistoryEntry(historyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && entry.ProductID != product.ID) {
+		return nil, invalidf("wpis historii nie istnieje dla tego produktu")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	newer, err := s.ProductRepo.GetProductHistoryAfterID(product.ID, historyID)
+	if err != nil {
+		return nil, err
+	}
+	return replayBackwards(currentSnapshot(product), newer)
+}
+
+// snapshotAt - Stan produktu w chwili t
+func (s *ProductService) snapshotAt(product *models.Product, t time.Time) (*productSnapshot, error) {
+	newer, err := s.ProductRepo.GetProductHistoryAfter(product.ID, t)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := replayBackwards(currentSnapshot(product), newer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Produkty sprzed rejestrowania utworzenia nie mają wpisu z pustą nazwą
+	if t.Before(product.CreatedAt) {
+		snapshot.Existed = false
+	}
+	return snapshot, nil
+}
+
+// RevertProduct - Przywraca produkt do stanu po wpisie historii historyID albo do stanu z chwili at
+func (s *ProductService) RevertProduct(ctx context.Context, id uint, historyID uint, at *time.Time, version uint) (*models.Product, error) {
+	if (historyID == 0) == (at == nil) {
+		return nil, invalidf("należy podać dokładnie jedno z pól: HistoryID albo At")
+	}
+
+	existingProduct, err := s.getExistingProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkVersion(existingProduct, version); err != nil {
+		return nil, err
+	}
+
+	var snapshot *productSnapshot
+	if historyID != 0 {
+		snapshot, err = s.snapshotAfterEntry(existingProduct, historyID)
+	} else {
+		snapshot, err = s.snapshotAt(existingProduct, *at)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !snapshot.Existed {
+		return nil, ErrProductDidNotExist
+	}
+
+	target := snapshot.Product
+	if err = s.validateProduct(&target); err != nil {
+		return nil, err
+	}
+	fields, err := s.screenBlacklist(existingProduct, &target, editableProductFields)
+	if err != nil {
+		return nil, err
+	}
+
+	if AuditInfoFromContext(ctx).Reason == "" {
+		if historyID != 0 {
+			ctx = WithReason(ctx, fmt.Sprintf("przywrócenie stanu po wpisie historii #%d", historyID))
+		} else {
+			ctx = WithReason(ctx, "przywrócenie stanu z "+at.Format(time.RFC3339))
+		}
+	}
+
+	if err = s.applyChanges(ctx, existingProduct, &target, fields); err != nil {
+		return nil, err
+	}
+
+	return existingProduct, nil
+}
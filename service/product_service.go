@@ -1,53 +1,102 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"product-controller/models"
 	"product-controller/repository"
 	"regexp"
 	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProductNotFound   = errors.New("produkt nie istnieje")
+	ErrProductNotInTrash = errors.New("produkt nie znajduje się w koszu")
 )
 
+// ValidationError - Niepoprawne dane wejściowe; pozostałe błędy bez własnego znaczenia (baza danych, błędy
+// wewnętrzne) kontrolery traktują jako błędy serwera
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// invalidf - Błąd walidacji z komunikatem w formacie fmt.Errorf
+func invalidf(format string, args ...interface{}) error {
+	return &ValidationError{Err: fmt.Errorf(format, args...)}
+}
+
 type ProductService struct {
-	ProductRepo   *repository.ProductRepository
-	BlacklistRepo *repository.BlacklistRepository
+	ProductRepo      *repository.ProductRepository
+	BlacklistService *BlacklistService
+	CategoryRepo     *repository.CategoryRepository
+	WarehouseRepo    *repository.WarehouseRepository
+	BlacklistMode    string // BlacklistModeReject (domyślnie) albo BlacklistModeQuarantine
 }
 
-func NewProductService(productRepo *repository.ProductRepository, blacklistRepo *repository.BlacklistRepository) *ProductService {
+func NewProductService(productRepo *repository.ProductRepository, blacklistService *BlacklistService, categoryRepo *repository.CategoryRepository, warehouseRepo *repository.WarehouseRepository) *ProductService {
 	return &ProductService{
-		ProductRepo:   productRepo,
-		BlacklistRepo: blacklistRepo,
+		ProductRepo:      productRepo,
+		BlacklistService: blacklistService,
+		CategoryRepo:     categoryRepo,
+		WarehouseRepo:    warehouseRepo,
 	}
 }
-func (s *ProductService) AddProduct(product *models.Product) error {
-	// Pobierz czarną listę słów
-	blacklist, err := s.BlacklistRepo.GetAllBlacklistWords()
-	if err != nil {
-		return err
-	}
-	if err = s.validateProduct(product); err != nil {
+func (s *ProductService) AddProduct(ctx context.Context, product *models.Product) error {
+	if err := s.validateProduct(product); err != nil {
 		return err
 	}
 
 	// Sprawdź, czy nazwa produktu zawiera zabronione słowo
-	for _, word := range blacklist {
-		if strings.Contains(strings.ToLower(product.Name), strings.ToLower(word.Word)) {
-			return errors.New("nazwa produktu zawiera zabronione słowo: " + word.Word)
-		}
+	product.Status = models.StatusActive
+	product.ModerationNote = ""
+	if _, err := s.screenBlacklist(nil, product, nil); err != nil {
+		return err
 	}
 
-	// Dodaj produkt
-	return s.ProductRepo.CreateProduct(product)
+	// Dodaj produkt wraz z początkowymi wpisami historii
+	product.Version = 1
+	product.Reserved = 0
+	changes := initialChanges(product)
+	if product.Status == models.StatusPending {
+		changes = append(changes, fieldChange{"Status", "", product.Status})
+	}
+	return s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.CreateProduct(product); err != nil {
+			return err
+		}
+		if err := tx.recordAdjustment(ctx, product, product.Quantity); err != nil {
+			return err
+		}
+		if err := tx.checkLowStock(&models.Product{}, product); err != nil {
+			return err
+		}
+		return tx.saveProductHistory(ctx, product.ID, changes...)
+	})
 }
 
-func (s *ProductService) UpdateProduct(id uint, updatedProduct *models.Product) error {
+// UpdateProduct - Pełna aktualizacja; version to oczekiwana wersja produktu (0 - dowolna)
+func (s *ProductService) UpdateProduct(ctx context.Context, id uint, updatedProduct *models.Product, version uint) error {
 	// Pobierz istniejący produkt
-	existingProduct, err := s.ProductRepo.GetProductByID(id)
+	existingProduct, err := s.getExistingProduct(id)
 	if err != nil {
 		return err
 	}
 
+	if err = checkVersion(existingProduct, version); err != nil {
+		return err
+	}
+
 	updatedProduct.ID = id
 
 	if err = s.validateProduct(updatedProduct); err != nil {
@@ -55,95 +104,470 @@ func (s *ProductService) UpdateProduct(id uint, updatedProduct *models.Product)
 	}
 
 	// Walidacja nazwy z blacklistą
-	blacklist, err := s.BlacklistRepo.GetAllBlacklistWords()
+	fields, err := s.screenBlacklist(existingProduct, updatedProduct, editableProductFields)
 	if err != nil {
 		return err
 	}
 
-	for _, word := range blacklist {
-		if strings.Contains(strings.ToLower(updatedProduct.Name), strings.ToLower(word.Word)) {
-			return errors.New("nazwa produktu zawiera zabronione słowo: " + word.Word)
-		}
+	if err = s.applyChanges(ctx, existingProduct, updatedProduct, fields); err != nil {
+		return err
+	}
+
+	*updatedProduct = *existingProduct
+	return nil
+}
+
+func (s *ProductService) getExistingProduct(id uint) (*models.Product, error) {
+	product, err := s.ProductRepo.GetProductByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotFound
+	}
+	return product, err
+}
+
+// checkVersion - Wersja 0 oznacza If-Match: *, czyli dowolną aktualną wersję
+func checkVersion(product *models.Product, version uint) error {
+	if version != 0 && product.Version != version {
+		return repository.ErrVersionConflict
 	}
+	return nil
+}
+
+// fieldChange - Zmiana pojedynczego pola, zapisywana w historii
+type fieldChange struct {
+	Field    string
+	OldValue string
+	NewValue string
+}
+
+// applyChanges - Przenosi wskazane pola do istniejącego produktu i zapisuje je razem z historią w jednej transakcji
+func (s *ProductService) applyChanges(ctx context.Context, existingProduct, updatedProduct *models.Product, fields map[string]bool) error {
+	var changes []fieldChange
+	var quantityDelta int
+	before := *existingProduct
 
-	// Zapis historii zmian
-	if existingProduct.Name != updatedProduct.Name {
-		s.saveProductHistory(id, "Name", existingProduct.Name, updatedProduct.Name)
+	if fields["Name"] && existingProduct.Name != updatedProduct.Name {
+		changes = append(changes, fieldChange{"Name", existingProduct.Name, updatedProduct.Name})
+	}
+	if fields["Category"] && existingProduct.Category != updatedProduct.Category {
+		changes = append(changes, fieldChange{"Category", existingProduct.Category, updatedProduct.Category})
 	}
-	if existingProduct.Price != updatedProduct.Price {
-		s.saveProductHistory(id, "Price", fmt.Sprintf("%.2f", existingProduct.Price), fmt.Sprintf("%.2f", updatedProduct.Price))
+	if fields["Price"] && existingProduct.Price != updatedProduct.Price {
+		changes = append(changes, fieldChange{"Price", fmt.Sprintf("%.2f", existingProduct.Price), fmt.Sprintf("%.2f", updatedProduct.Price)})
 	}
-	if existingProduct.Quantity != updatedProduct.Quantity {
-		s.saveProductHistory(id, "Quantity", fmt.Sprintf("%d", existingProduct.Quantity), fmt.Sprintf("%d", updatedProduct.Quantity))
+	if fields["Quantity"] && existingProduct.Quantity != updatedProduct.Quantity {
+		changes = append(changes, fieldChange{"Quantity", fmt.Sprintf("%d", existingProduct.Quantity), fmt.Sprintf("%d", updatedProduct.Quantity)})
+		quantityDelta = updatedProduct.Quantity - existingProduct.Quantity
 	}
-	if existingProduct.Description != updatedProduct.Description {
-		s.saveProductHistory(id, "Description", existingProduct.Description, updatedProduct.Description)
+	if fields["Description"] && existingProduct.Description != updatedProduct.Description {
+		changes = append(changes, fieldChange{"Description", existingProduct.Description, updatedProduct.Description})
+	}
+	if fields["ReorderPoint"] && existingProduct.ReorderPoint != updatedProduct.ReorderPoint {
+		changes = append(changes, fieldChange{"ReorderPoint", fmt.Sprintf("%d", existingProduct.ReorderPoint), fmt.Sprintf("%d", updatedProduct.ReorderPoint)})
+	}
+	if fields["ReorderQuantity"] && existingProduct.ReorderQuantity != updatedProduct.ReorderQuantity {
+		changes = append(changes, fieldChange{"ReorderQuantity", fmt.Sprintf("%d", existingProduct.ReorderQuantity), fmt.Sprintf("%d", updatedProduct.ReorderQuantity)})
+	}
+	if fields["Status"] && existingProduct.Status != updatedProduct.Status {
+		changes = append(changes, fieldChange{"Status", existingProduct.Status, updatedProduct.Status})
 	}
 
 	// Aktualizacja produktu
-	existingProduct.Name = updatedProduct.Name
-	existingProduct.Description = updatedProduct.Description
-	existingProduct.Price = updatedProduct.Price
-	existingProduct.Quantity = updatedProduct.Quantity
+	if fields["Name"] {
+		existingProduct.Name = updatedProduct.Name
+	}
+	if fields["Category"] {
+		existingProduct.Category = updatedProduct.Category
+	}
+	if fields["Description"] {
+		existingProduct.Description = updatedProduct.Description
+	}
+	if fields["Price"] {
+		existingProduct.Price = updatedProduct.Price
+	}
+	if fields["Quantity"] {
+		existingProduct.Quantity = updatedProduct.Quantity
+	}
+	if fields["ReorderPoint"] {
+		existingProduct.ReorderPoint = updatedProduct.ReorderPoint
+	}
+	if fields["ReorderQuantity"] {
+		existingProduct.ReorderQuantity = updatedProduct.ReorderQuantity
+	}
+	if fields["Status"] {
+		existingProduct.Status = updatedProduct.Status
+		existingProduct.ModerationNote = updatedProduct.ModerationNote
+	}
+
+	return s.inTransaction(func(tx *ProductService) error {
+		// Najpierw produkt: warunek na wersję blokuje wiersz przed zapisem historii
+		if err := tx.ProductRepo.UpdateProduct(existingProduct); err != nil {
+			return err
+		}
+		if err := tx.recordAdjustment(ctx, existingProduct, quantityDelta); err != nil {
+			return err
+		}
+		if err := tx.checkLowStock(&before, existingProduct); err != nil {
+			return err
+		}
+		return tx.saveProductHistory(ctx, existingProduct.ID, changes...)
+	})
+}
 
-	return s.ProductRepo.UpdateProduct(existingProduct)
+// initialChanges - Wpisy historii dla nowo utworzonego produktu (puste wartości poprzednie);
+// próg zamówienia tylko wtedy, gdy został ustawiony
+func initialChanges(product *models.Product) []fieldChange {
+	changes := []fieldChange{
+		{"Name", "", product.Name},
+		{"Category", "", product.Category},
+		{"Description", "", product.Description},
+		{"Price", "", fmt.Sprintf("%.2f", product.Price)},
+		{"Quantity", "", fmt.Sprintf("%d", product.Quantity)},
+	}
+	if product.ReorderPoint != 0 || product.ReorderQuantity != 0 {
+		changes = append(changes,
+			fieldChange{"ReorderPoint", "", fmt.Sprintf("%d", product.ReorderPoint)},
+			fieldChange{"ReorderQuantity", "", fmt.Sprintf("%d", product.ReorderQuantity)})
+	}
+	return changes
+}
+
+// inTransaction - Uruchamia fn na kopii serwisu, której repozytorium produktów działa w transakcji
+func (s *ProductService) inTransaction(fn func(tx *ProductService) error) error {
+	return s.ProductRepo.Transaction(func(repo *repository.ProductRepository) error {
+		txService := *s
+		txService.ProductRepo = repo
+		return fn(&txService)
+	})
 }
 
-func (s *ProductService) saveProductHistory(productID uint, field, oldValue, newValue string) {
-	history := models.ProductHistory{
-		ProductID: productID,
-		Field:     field,
-		OldValue:  oldValue,
-		NewValue:  newValue,
+// screenBlacklist - Sprawdzenie blacklisty przed zapisem. W trybie kwarantanny naruszenie nie odrzuca zapisu,
+// tylko kieruje produkt do moderacji; zwraca pola do zapisania uzupełnione o status. Przy aktualizacji (existing
+// różne od nil) sprawdzamy tylko zmieniony tekst: decyzja moderatora dotyczy tekstu, który zatwierdził, więc np.
+// zmiana ceny nie cofa zatwierdzonego produktu do moderacji ani nie blokuje jego edycji
+func (s *ProductService) screenBlacklist(existing, product *models.Product, fields map[string]bool) (map[string]bool, error) {
+	if existing != nil && !blacklistTextChanged(existing, product, fields) {
+		return fields, nil
+	}
+
+	err := s.checkBlacklist(product)
+	var violation *BlacklistViolation
+	if err == nil || s.BlacklistMode != BlacklistModeQuarantine || !errors.As(err, &violation) {
+		return fields, err
 	}
-	s.ProductRepo.SaveProductHistory(&history)
+
+	product.Status = models.StatusPending
+	product.ModerationNote = violation.Error()
+
+	withStatus := map[string]bool{"Status": true}
+	for field := range fields {
+		withStatus[field] = fields[field]
+	}
+	return withStatus, nil
+}
+
+// blacklistTextChanged - Czy zapis zmienia pola, od których zależy wynik sprawdzenia blacklisty;
+// kategoria wyznacza zestaw obowiązujących reguł
+func blacklistTextChanged(existing, product *models.Product, fields map[string]bool) bool {
+	return fields["Name"] && existing.Name != product.Name ||
+		fields["Description"] && existing.Description != product.Description ||
+		fields["Category"] && existing.Category != product.Category
 }
 
-func (s *ProductService) DeleteProduct(id uint) error {
-	return s.ProductRepo.DeleteProduct(id)
+// checkBlacklist - Sprawdzenie nazwy i opisu względem reguł blacklisty obowiązujących w kategorii produktu
+func (s *ProductService) checkBlacklist(product *models.Product) error {
+	fields := []struct{ name, text string }{
+		{"Name", product.Name},
+		{"Description", product.Description},
+	}
+	for _, field := range fields {
+		violation, err := s.BlacklistService.Check(field.name, product.Category, field.text)
+		if err != nil {
+			return err
+		}
+		if violation != nil {
+			return violation
+		}
+	}
+
+	return nil
 }
-func (s *ProductService) GetProductHistory(productID uint) ([]models.ProductHistory, error) {
-	return s.ProductRepo.GetProductHistory(productID)
+
+func (s *ProductService) saveProductHistory(ctx context.Context, productID uint, changes ...fieldChange) error {
+	audit := AuditInfoFromContext(ctx)
+	for _, change := range changes {
+		history := models.ProductHistory{
+			ProductID: productID,
+			Field:     change.Field,
+			OldValue:  change.OldValue,
+			NewValue:  change.NewValue,
+			AuditInfo: audit,
+		}
+		if err := s.chainHistory(&history); err != nil {
+			return err
+		}
+		if err := s.ProductRepo.SaveProductHistory(&history); err != nil {
+			return err
+		}
+		if err := s.saveHistoryHead(&history); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *ProductService) DeleteProduct(ctx context.Context, id uint, version uint) error {
+	existingProduct, err := s.getExistingProduct(id)
+	if err != nil {
+		return err
+	}
+
+	if err = checkVersion(existingProduct, version); err != nil {
+		return err
+	}
+
+	return s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.DeleteProduct(id, existingProduct.Version); err != nil {
+			return err
+		}
+		return tx.saveProductHistory(ctx, id, fieldChange{"Deleted", "false", "true"})
+	})
+}
+
+func (s *ProductService) GetDeletedProducts() ([]models.Product, error) {
+	return s.ProductRepo.GetDeletedProducts()
+}
+
+func (s *ProductService) RestoreProduct(ctx context.Context, id uint) (*models.Product, error) {
+	product, err := s.getDeletedProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.RestoreProduct(product); err != nil {
+			return err
+		}
+		return tx.saveProductHistory(ctx, id, fieldChange{"Deleted", "true", "false"})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// setProductStatus - Zmiana statusu publikacji wraz z wpisem historii; note to powód widoczny dla moderatora
+func (s *ProductService) setProductStatus(ctx context.Context, product *models.Product, status, note string) error {
+	oldStatus := product.Status
+	return s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.SetProductStatus(product, status, note); err != nil {
+			return err
+		}
+		return tx.saveProductHistory(ctx, product.ID, fieldChange{"Status", oldStatus, status})
+	})
+}
+
+// PurgeProduct - Trwale usuwa produkt z kosza wraz ze stanami magazynowymi; historia zmian i ruchy pozostają
+func (s *ProductService) PurgeProduct(id uint) error {
+	if _, err := s.getDeletedProduct(id); err != nil {
+		return err
+	}
+
+	return s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.PurgeProduct(id); err != nil {
+			return err
+		}
+		return tx.ProductRepo.DeleteStockLevels(id)
+	})
+}
+
+func (s *ProductService) getDeletedProduct(id uint) (*models.Product, error) {
+	product, err := s.ProductRepo.GetDeletedProductByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotInTrash
+	}
+	return product, err
+}
+
+// ProductList - Strona listy produktów wraz z informacjami o stronicowaniu
+type ProductList struct {
+	Products []models.Product
+	Total    int64
+	HasNext  bool
+	HasPrev  bool
+	Next     *repository.Keyset
+	Prev     *repository.Keyset
+}
+
+func (s *ProductService) ListProducts(query repository.ProductQuery) (*ProductList, error) {
+	total, err := s.ProductRepo.CountProducts(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pobieramy jeden rekord więcej, żeby wiedzieć, czy istnieje kolejna strona
+	limit := query.Limit
+	query.Limit = limit + 1
+	products, err := s.ProductRepo.FindProducts(query)
+	if err != nil {
+		return nil, err
+	}
+
+	more := len(products) > limit
+	if more {
+		products = products[:limit]
+	}
+
+	list := &ProductList{Total: total}
+
+	k := query.Keyset
+	if k == nil {
+		list.Products = products
+		list.HasPrev = query.Offset > 0
+		list.HasNext = more
+		return list, nil
+	}
+
+	if k.Backward {
+		for i, j := 0, len(products)-1; i < j; i, j = i+1, j-1 {
+			products[i], products[j] = products[j], products[i]
+		}
+		list.HasPrev = more
+		list.HasNext = k.ID != 0
+	} else {
+		list.HasPrev = k.ID != 0
+		list.HasNext = more
+	}
+	list.Products = products
+
+	if len(products) > 0 {
+		first, last := products[0], products[len(products)-1]
+		list.Next = &repository.Keyset{Column: k.Column, Desc: k.Desc, ID: last.ID, UpdatedAt: last.UpdatedAt}
+		list.Prev = &repository.Keyset{Column: k.Column, Desc: k.Desc, Backward: true, ID: first.ID, UpdatedAt: first.UpdatedAt}
+	}
+
+	return list, nil
+}
+
+// HistoryPage - Strona historii zmian wraz z kursorem następnej strony
+type HistoryPage struct {
+	Entries []models.ProductHistory
+	Total   int64
+	Next    *repository.Keyset
+}
+
+func (s *ProductService) ListHistory(query repository.HistoryQuery) (*HistoryPage, error) {
+	total, err := s.ProductRepo.CountProductHistory(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	query.Limit = limit + 1
+	entries, err := s.ProductRepo.FindProductHistory(query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{Entries: entries, Total: total}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		page.Next = &repository.Keyset{Column: "id", Desc: query.Keyset.Desc, ID: last.ID}
+	}
+
+	return page, nil
 }
 
 func (s *ProductService) validateProduct(product *models.Product) error {
+	return s.validateFields(product, editableProductFields)
+}
+
+// validateFields - Walidacja wskazanych pól; cena jest sprawdzana również przy zmianie kategorii
+func (s *ProductService) validateFields(product *models.Product, fields map[string]bool) error {
+	if fields["Name"] {
+		if err := s.validateName(product); err != nil {
+			return err
+		}
+	}
+
+	if fields["Category"] || fields["Price"] {
+		if err := s.validateCategoryAndPrice(product); err != nil {
+			return err
+		}
+	}
+
+	if fields["Quantity"] && product.Quantity < 0 {
+		return repository.ErrNegativeStock
+	}
+
+	if fields["ReorderPoint"] || fields["ReorderQuantity"] {
+		if err := validateReorder(product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateReorder - Próg zamówienia 0 wyłącza alerty; ustawiony próg wymaga dodatniej ilości zamówienia
+func validateReorder(product *models.Product) error {
+	if product.ReorderPoint < 0 || product.ReorderQuantity < 0 {
+		return invalidf("próg i ilość zamówienia nie mogą być ujemne")
+	}
+	if product.ReorderPoint > 0 && product.ReorderQuantity == 0 {
+		return invalidf("ilość zamówienia musi być dodatnia, gdy ustawiono próg zamówienia")
+	}
+	return nil
+}
+
+func (s *ProductService) validateName(product *models.Product) error {
 	// Walidacja nazwy
 	if len(product.Name) < 3 || len(product.Name) > 20 {
-		return errors.New("nazwa produktu musi mieć od 3 do 20 znaków")
+		return invalidf("nazwa produktu musi mieć od 3 do 20 znaków")
 	}
 
 	namePattern := `^[a-zA-Z0-9]+$`
 	matched, _ := regexp.MatchString(namePattern, product.Name)
 	if !matched {
-		return errors.New("nazwa produktu może zawierać tylko litery i cyfry")
+		return invalidf("nazwa produktu może zawierać tylko litery i cyfry")
 	}
 
-	existing, _ := s.ProductRepo.GetProductByName(product.Name)
+	// Unikalny indeks obejmuje też produkty w koszu, więc sprawdzamy je razem z aktywnymi
+	existing, _ := s.ProductRepo.GetProductByNameUnscoped(product.Name)
 	if existing != nil && existing.ID != product.ID {
-		return errors.New("produkt o tej nazwie już istnieje")
+		if existing.DeletedAt.Valid {
+			return invalidf("produkt o tej nazwie znajduje się w koszu (ID %d), przywróć go albo usuń trwale", existing.ID)
+		}
+		return invalidf("produkt o tej nazwie już istnieje")
 	}
 
-	var minPrice, maxPrice float64
-	switch strings.ToLower(product.Category) {
-	case "elektronika":
-		minPrice = 50
-		maxPrice = 50000
-	case "książki":
-		minPrice = 5
-		maxPrice = 500
-	case "odzież":
-		minPrice = 10
-		maxPrice = 5000
-	default:
-		return errors.New("kategoria musi być jedną z: Elektronika, Książki, Odzież")
-	}
+	return nil
+}
 
-	if product.Price < minPrice || product.Price > maxPrice {
-		return fmt.Errorf("cena produktu w kategorii %s musi być w przedziale %.2f - %.2f", product.Category, minPrice, maxPrice)
+func (s *ProductService) validateCategoryAndPrice(product *models.Product) error {
+	category, err := s.CategoryRepo.GetCategoryByName(product.Category)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		categories, err := s.CategoryRepo.GetAllCategories()
+		if err != nil {
+			return err
+		}
+		names := make([]string, len(categories))
+		for i, c := range categories {
+			names[i] = c.Name
+		}
+		return invalidf("kategoria musi być jedną z: %s", strings.Join(names, ", "))
 	}
+	if err != nil {
+		return err
+	}
+
+	// Zapisujemy nazwę kategorii w takiej postaci, w jakiej jest w bazie
+	product.Category = category.Name
 
-	if product.Quantity < 0 {
-		return errors.New("ilość produktów nie może być ujemna")
+	if product.Price < category.MinPrice || product.Price > category.MaxPrice {
+		return invalidf("cena produktu w kategorii %s musi być w przedziale %.2f - %.2f", product.Category, category.MinPrice, category.MaxPrice)
 	}
 
 	return nil
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTimeRange = errors.New("początek przedziału musi być wcześniejszy niż koniec")
+
+// ProductSnapshot - Stan produktu w danej chwili, odtworzony z historii
+type ProductSnapshot struct {
+	ID              uint
+	Name            string
+	Category        string
+	Description     string
+	Price           float64
+	Quantity        int
+	ReorderPoint    int
+	ReorderQuantity int
+	Status          string
+	Deleted         bool
+	AsOf            time.Time
+}
+
+// FieldDiff - Różnica wartości jednego pola pomiędzy dwiema chwilami
+type FieldDiff struct {
+	Field string
+	From  string
+	To    string
+}
+
+// ProductDiff - Zmiany produktu pomiędzy chwilami From i To
+type ProductDiff struct {
+	ProductID uint
+	From      time.Time
+	To        time.Time
+	Changes   []FieldDiff
+}
+
+func (s *ProductService) GetProductAsOf(id uint, t time.Time) (*ProductSnapshot, error) {
+	product, err := s.ProductRepo.GetProductByIDUnscoped(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := s.snapshotAt(product, t)
+	if err != nil {
+		return nil, err
+	}
+	if !snapshot.Existed {
+		return nil, ErrProductDidNotExist
+	}
+
+	return snapshot.export(t), nil
+}
+
+func (s *ProductService) GetProductDiff(id uint, from, to time.Time) (*ProductDiff, error) {
+	if from.After(to) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	product, err := s.ProductRepo.GetProductByIDUnscoped(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	end, err := s.snapshotAt(product, to)
+	if err != nil {
+		return nil, err
+	}
+	if !end.Existed {
+		return nil, ErrProductDidNotExist
+	}
+
+	// Stan początkowy odtwarzamy od stanu końcowego, cofając tylko wpisy z przedziału
+	between, err := s.ProductRepo.GetProductHistoryBetween(id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	start, err := replayBackwards(*end, between)
+	if err != nil {
+		return nil, err
+	}
+	if from.Before(product.CreatedAt) {
+		start.Existed = false
+	}
+
+	diff := &ProductDiff{ProductID: id, From: from, To: to, Changes: []FieldDiff{}}
+	startValues, endValues := start.values(), end.values()
+	for _, field := range snapshotFields {
+		if startValues[field] != endValues[field] {
+			diff.Changes = append(diff.Changes, FieldDiff{Field: field, From: startValues[field], To: endValues[field]})
+		}
+	}
+
+	return diff, nil
+}
+
+var snapshotFields = []string{"Name", "Category", "Description", "Price", "Quantity", "ReorderPoint", "ReorderQuantity", "Status", "Deleted"}
+
+// values - Wartości pól w postaci zapisywanej w historii; nieistniejący produkt ma puste wartości
+func (s *productSnapshot) values() map[string]string {
+	if !s.Existed {
+		return map[string]string{}
+	}
+
+	p := s.Product
+	return map[string]string{
+		"Name":            p.Name,
+		"Category":        p.Category,
+		"Description":     p.Description,
+		"Price":           fmt.Sprintf("%.2f", p.Price),
+		"Quantity":        fmt.Sprintf("%d", p.Quantity),
+		"ReorderPoint":    fmt.Sprintf("%d", p.ReorderPoint),
+		"ReorderQuantity": fmt.Sprintf("%d", p.ReorderQuantity),
+		"Status":          p.Status,
+		"Deleted":         fmt.Sprintf("%t", s.Deleted),
+	}
+}
+
+func (s *productSnapshot) export(t time.Time) *ProductSnapshot {
+	return &ProductSnapshot{
+		ID:              s.Product.ID,
+		Name:            s.Product.Name,
+		Category:        s.Product.Category,
+		Description:     s.Product.Description,
+		Price:           s.Product.Price,
+		Quantity:        s.Product.Quantity,
+		ReorderPoint:    s.Product.ReorderPoint,
+		ReorderQuantity: s.Product.ReorderQuantity,
+		Status:          s.Product.Status,
+		Deleted:         s.Deleted,
+		AsOf:            t,
+	}
+}
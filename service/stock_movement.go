@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-controller/models"
+)
+
+// ErrStockInWarehouses - Bezpośrednia zmiana ilości produktu, którego stan nie leży wyłącznie w magazynie domyślnym
+var ErrStockInWarehouses = errors.New("produkt ma stan w innych magazynach niż domyślny, zmień ilość ruchem magazynowym we wskazanym magazynie")
+
+// Znak ilości wymagany dla rodzaju ruchu: 1 - dodatnia, -1 - ujemna, 0 - dowolna różna od zera
+var stockMovementSigns = map[string]int{
+	models.MovementReceipt:    1,
+	models.MovementSale:       -1,
+	models.MovementAdjustment: 0,
+	models.MovementReturn:     1,
+	models.MovementWriteOff:   -1,
+}
+
+// ValidateStockMovement - Sprawdzenie rodzaju ruchu, znaku ilości i długości referencji
+func ValidateStockMovement(movement *models.StockMovement) error {
+	sign, ok := stockMovementSigns[movement.Type]
+	if !ok {
+		return invalidf("rodzaj ruchu musi być jednym z: %s, %s, %s, %s, %s",
+			models.MovementReceipt, models.MovementSale, models.MovementAdjustment, models.MovementReturn, models.MovementWriteOff)
+	}
+
+	switch {
+	case movement.Quantity == 0:
+		return invalidf("ilość w ruchu magazynowym nie może być zerowa")
+	case sign > 0 && movement.Quantity < 0:
+		return invalidf("ruch %s musi mieć dodatnią ilość", movement.Type)
+	case sign < 0 && movement.Quantity > 0:
+		return invalidf("ruch %s musi mieć ujemną ilość", movement.Type)
+	}
+
+	if len(movement.Reference) > 100 {
+		return invalidf("referencja ruchu może mieć najwyżej 100 znaków")
+	}
+
+	return nil
+}
+
+// PostStockMovement - Zaksięgowanie ruchu magazynowego w magazynie o kodzie warehouse (pusty - domyślny): stan
+// produktu, stan w magazynie, ruch i wpis historii w jednej transakcji. version to oczekiwana wersja produktu (0 - dowolna)
+func (s *ProductService) PostStockMovement(ctx context.Context, productID uint, warehouse string, movement *models.StockMovement, version uint) (*models.Product, error) {
+	if err := ValidateStockMovement(movement); err != nil {
+		return nil, err
+	}
+
+	target, err := s.resolveWarehouse(warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = s.getExistingProduct(productID); err != nil {
+		return nil, err
+	}
+
+	var product *models.Product
+	err = s.inTransaction(func(tx *ProductService) error {
+		var err error
+		// Najpierw produkt: blokada jego wiersza porządkuje zmiany stanów w magazynach
+		product, err = tx.ProductRepo.AdjustProductQuantity(productID, movement.Quantity, version)
+		if err != nil {
+			return err
+		}
+		if movement.Type == models.MovementSale && !product.Sellable() {
+			return ErrProductNotSellable
+		}
+		if err = tx.ProductRepo.AdjustStockLevel(productID, target.ID, movement.Quantity); err != nil {
+			return err
+		}
+
+		movement.WarehouseID = target.ID
+		return tx.recordMovement(ctx, product, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// recordMovement - Zapis ruchu, który już zmienił stan produktu, wraz z wpisem historii ilości i ewentualnym alertem
+func (s *ProductService) recordMovement(ctx context.Context, product *models.Product, movement *models.StockMovement) error {
+	movement.ProductID = product.ID
+	movement.BalanceAfter = product.Quantity
+	if err := s.saveStockMovement(ctx, movement); err != nil {
+		return err
+	}
+
+	before := *product
+	before.Quantity -= movement.Quantity
+	if err := s.checkLowStock(&before, product); err != nil {
+		return err
+	}
+
+	return s.saveProductHistory(ctx, product.ID,
+		fieldChange{"Quantity", fmt.Sprintf("%d", before.Quantity), fmt.Sprintf("%d", product.Quantity)})
+}
+
+func (s *ProductService) saveStockMovement(ctx context.Context, movement *models.StockMovement) error {
+	movement.AuditInfo = AuditInfoFromContext(ctx)
+	return s.ProductRepo.SaveStockMovement(movement)
+}
+
+// recordAdjustment - Zmiana ilości spoza rejestru ruchów (utworzenie, edycja, import, przywrócenie wersji)
+// zapisywana jako korekta w magazynie domyślnym, żeby suma ruchów i stanów zgadzała się ze stanem produktu.
+// Gdy produkt ma stan w innych magazynach, nie wiadomo, którego dotyczy zmiana, więc jest odrzucana
+func (s *ProductService) recordAdjustment(ctx context.Context, product *models.Product, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+
+	warehouse, err := s.resolveWarehouse("")
+	if err != nil {
+		return err
+	}
+	elsewhere, err := s.ProductRepo.HasStockOutsideWarehouse(product.ID, warehouse.ID)
+	if err != nil {
+		return err
+	}
+	if elsewhere {
+		return ErrStockInWarehouses
+	}
+	if err = s.ProductRepo.AdjustStockLevel(product.ID, warehouse.ID, delta); err != nil {
+		return err
+	}
+
+	return s.saveStockMovement(ctx, &models.StockMovement{
+		ProductID:    product.ID,
+		WarehouseID:  warehouse.ID,
+		Type:         models.MovementAdjustment,
+		Quantity:     delta,
+		BalanceAfter: product.Quantity,
+	})
+}
+
+func (s *ProductService) GetStockMovements(productID uint, movementType string, limit int) ([]models.StockMovement, error) {
+	if _, err := s.getExistingProduct(productID); err != nil {
+		return nil, err
+	}
+	return s.ProductRepo.FindStockMovements(productID, movementType, limit)
+}
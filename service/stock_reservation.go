@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"product-controller/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("rezerwacja nie istnieje")
+	ErrReservationNotActive = errors.New("rezerwacja nie jest aktywna albo już wygasła")
+)
+
+const (
+	DefaultReservationTTL    = 15 * time.Minute
+	MaxReservationTTL        = 24 * time.Hour
+	ReservationSweepInterval = 30 * time.Second
+
+	// Liczba wygasłych rezerwacji zwalnianych w jednej partii
+	reservationSweepBatch = 100
+)
+
+// ReserveStock - Wstrzymanie quantity sztuk produktu w magazynie o kodzie warehouse (pusty - domyślny) na czas ttl
+// (0 - DefaultReservationTTL). Warunek w bazie sprawia, że równoległe rezerwacje nie przekroczą dostępnej ilości
+func (s *ProductService) ReserveStock(ctx context.Context, productID uint, warehouse string, quantity int, ttl time.Duration, reference string) (*models.StockReservation, error) {
+	if quantity <= 0 {
+		return nil, invalidf("ilość rezerwacji musi być większa od zera")
+	}
+	if ttl == 0 {
+		ttl = DefaultReservationTTL
+	}
+	if ttl < 0 || ttl > MaxReservationTTL {
+		return nil, invalidf("czas ważności rezerwacji musi być w przedziale od 1 s do %s", MaxReservationTTL)
+	}
+	if len(reference) > 100 {
+		return nil, invalidf("referencja rezerwacji może mieć najwyżej 100 znaków")
+	}
+
+	source, err := s.resolveWarehouse(warehouse)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.getExistingProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+	if !product.Sellable() {
+		return nil, ErrProductNotSellable
+	}
+
+	reservation := &models.StockReservation{
+		ProductID:   productID,
+		WarehouseID: source.ID,
+		Quantity:    quantity,
+		Status:      models.ReservationActive,
+		ExpiresAt:   time.Now().Add(ttl),
+		Reference:   reference,
+		AuditInfo:   AuditInfoFromContext(ctx),
+	}
+	err = s.inTransaction(func(tx *ProductService) error {
+		if err := tx.ProductRepo.ReserveProductQuantity(productID, quantity); err != nil {
+			return err
+		}
+		if err := tx.ProductRepo.ReserveStockLevel(productID, source.ID, quantity); err != nil {
+			return err
+		}
+		return tx.ProductRepo.CreateReservation(reservation)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// ConfirmReservation - Zamiana ważnej rezerwacji na sprzedaż: stan produktu maleje o zarezerwowaną ilość
+func (s *ProductService) ConfirmReservation(ctx context.Context, productID uint, reservationID uint) (*models.StockReservation, *models.Product, error) {
+	reservation, err := s.getReservation(productID, reservationID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var product *models.Product
+	err = s.inTransaction(func(tx *ProductService) error {
+		closed, err := tx.ProductRepo.CloseReservation(reservation.ID, models.ReservationConfirmed, time.Now(), AuditInfoFromContext(ctx))
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return ErrReservationNotActive
+		}
+
+		product, err = tx.ProductRepo.ConsumeReservedQuantity(productID, reservation.Quantity)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Produkt mógł zostać ukryty albo trafić do moderacji po utworzeniu rezerwacji
+		if !product.Sellable() {
+			return ErrProductNotSellable
+		}
+		if err = tx.ProductRepo.ConsumeStockLevel(productID, reservation.WarehouseID, reservation.Quantity); err != nil {
+			return err
+		}
+
+		reference := reservation.Reference
+		if reference == "" {
+			reference = fmt.Sprintf("rezerwacja #%d", reservation.ID)
+		}
+		return tx.recordMovement(ctx, product, &models.StockMovement{
+			WarehouseID: reservation.WarehouseID,
+			Type:        models.MovementSale,
+			Quantity:    -reservation.Quantity,
+			Reference:   reference,
+		})
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservation.Status = models.ReservationConfirmed
+	reservation.Closed = AuditInfoFromContext(ctx)
+	return reservation, product, nil
+}
+
+// ReleaseReservation - Zwolnienie aktywnej rezerwacji przed upływem ważności
+func (s *ProductService) ReleaseReservation(ctx context.Context, productID uint, reservationID uint) (*models.StockReservation, error) {
+	reservation, err := s.getReservation(productID, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.closeReservation(ctx, reservation, models.ReservationReleased, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+// closeReservation - Zamknięcie rezerwacji bez sprzedaży i oddanie wstrzymanej ilości; autor zamknięcia pochodzi z ctx
+func (s *ProductService) closeReservation(ctx context.Context, reservation *models.StockReservation, status string, now time.Time) error {
+	audit := AuditInfoFromContext(ctx)
+	err := s.inTransaction(func(tx *ProductService) error {
+		closed, err := tx.ProductRepo.CloseReservation(reservation.ID, status, now, audit)
+		if err != nil {
+			return err
+		}
+		if !closed {
+			return ErrReservationNotActive
+		}
+		if err = tx.ProductRepo.ReleaseProductQuantity(reservation.ProductID, reservation.Quantity); err != nil {
+			return err
+		}
+		return tx.ProductRepo.ReleaseStockLevel(reservation.ProductID, reservation.WarehouseID, reservation.Quantity)
+	})
+	if err != nil {
+		return err
+	}
+
+	reservation.Status = status
+	reservation.Closed = audit
+	return nil
+}
+
+func (s *ProductService) getReservation(productID uint, reservationID uint) (*models.StockReservation, error) {
+	reservation, err := s.ProductRepo.GetReservationByID(reservationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && reservation.ProductID != productID) {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, err
+}
+
+func (s *ProductService) GetReservations(productID uint, status string, limit int) ([]models.StockReservation, error) {
+	if _, err := s.getExistingProduct(productID); err != nil {
+		return nil, err
+	}
+	return s.ProductRepo.FindReservations(productID, status, limit)
+}
+
+// ExpireReservations - Zwolnienie rezerwacji, których ważność minęła przed chwilą now; zwraca ich liczbę.
+// Rezerwacje potwierdzone lub zwolnione w międzyczasie są pomijane
+func (s *ProductService) ExpireReservations(now time.Time) (int, error) {
+	ctx := WithReason(context.Background(), "rezerwacja wygasła")
+	expired := 0
+	for {
+		reservations, err := s.ProductRepo.FindExpiredReservations(now, reservationSweepBatch)
+		if err != nil {
+			return expired, err
+		}
+
+		for i := range reservations {
+			err = s.closeReservation(ctx, &reservations[i], models.ReservationExpired, now)
+			if errors.Is(err, ErrReservationNotActive) {
+				continue
+			}
+			if err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(reservations) < reservationSweepBatch {
+			return expired, nil
+		}
+	}
+}
+
+// RunReservationSweeper - Cykliczne zwalnianie wygasłych rezerwacji do czasu anulowania ctx
+func (s *ProductService) RunReservationSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			expired, err := s.ExpireReservations(now)
+			if err != nil {
+				log.Println("Błąd zwalniania wygasłych rezerwacji:", err)
+			}
+			if expired > 0 {
+				log.Printf("Zwolniono wygasłe rezerwacje: %d", expired)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-controller/models"
+	"product-controller/repository"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWarehouseNotFound = errors.New("magazyn nie istnieje")
+	ErrWarehouseExists   = errors.New("magazyn o tym kodzie już istnieje")
+)
+
+var warehouseCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{2,20}$`)
+
+type WarehouseService struct {
+	WarehouseRepo *repository.WarehouseRepository
+}
+
+func NewWarehouseService(warehouseRepo *repository.WarehouseRepository) *WarehouseService {
+	return &WarehouseService{
+		WarehouseRepo: warehouseRepo,
+	}
+}
+
+func (s *WarehouseService) GetAllWarehouses() ([]models.Warehouse, error) {
+	return s.WarehouseRepo.GetAllWarehouses()
+}
+
+func (s *WarehouseService) AddWarehouse(warehouse *models.Warehouse) error {
+	warehouse.ID = 0
+	warehouse.Code = strings.ToUpper(strings.TrimSpace(warehouse.Code))
+	warehouse.Name = strings.TrimSpace(warehouse.Name)
+
+	if !warehouseCodePattern.MatchString(warehouse.Code) {
+		return invalidf("kod magazynu musi mieć od 2 do 20 znaków: litery, cyfry, - lub _")
+	}
+	if utf8.RuneCountInString(warehouse.Name) < 2 || utf8.RuneCountInString(warehouse.Name) > 100 {
+		return invalidf("nazwa magazynu musi mieć od 2 do 100 znaków")
+	}
+
+	existing, _ := s.WarehouseRepo.GetWarehouseByCode(warehouse.Code)
+	if existing != nil {
+		return ErrWarehouseExists
+	}
+
+	return s.WarehouseRepo.CreateWarehouse(warehouse)
+}
+
+// GetWarehouseStock - Stany wszystkich produktów w magazynie
+func (s *WarehouseService) GetWarehouseStock(id uint) ([]models.StockLevel, error) {
+	if _, err := s.WarehouseRepo.GetWarehouseByID(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWarehouseNotFound
+		}
+		return nil, err
+	}
+	return s.WarehouseRepo.GetStockLevelsByWarehouse(id)
+}
+
+// resolveWarehouse - Magazyn o wskazanym kodzie; pusty kod oznacza magazyn domyślny
+func (s *ProductService) resolveWarehouse(code string) (*models.Warehouse, error) {
+	if code == "" {
+		code = models.DefaultWarehouseCode
+	}
+
+	warehouse, err := s.WarehouseRepo.GetWarehouseByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrWarehouseNotFound, code)
+	}
+	return warehouse, err
+}
+
+// GetProductStock - Stany produktu w poszczególnych magazynach
+func (s *ProductService) GetProductStock(productID uint) ([]models.StockLevel, error) {
+	if _, err := s.getExistingProduct(productID); err != nil {
+		return nil, err
+	}
+	return s.WarehouseRepo.GetStockLevelsByProduct(productID)
+}
+
+// TransferStock - Przesunięcie quantity sztuk produktu między magazynami w jednej transakcji; łączny stan produktu
+// się nie zmienia, a w rejestrze ruchów powstaje para ruchów transfer o przeciwnych znakach
+func (s *ProductService) TransferStock(ctx context.Context, productID uint, from, to string, quantity int, reference string) ([]models.StockLevel, error) {
+	if from == "" || to == "" {
+		return nil, invalidf("należy wskazać magazyn źródłowy i docelowy")
+	}
+	if quantity <= 0 {
+		return nil, invalidf("ilość przesunięcia musi być większa od zera")
+	}
+	if len(reference) > 100 {
+		return nil, invalidf("referencja przesunięcia może mieć najwyżej 100 znaków")
+	}
+
+	source, err := s.resolveWarehouse(from)
+	if err != nil {
+		return nil, err
+	}
+	target, err := s.resolveWarehouse(to)
+	if err != nil {
+		return nil, err
+	}
+	if source.ID == target.ID {
+		return nil, invalidf("magazyn źródłowy i docelowy muszą być różne")
+	}
+
+	err = s.inTransaction(func(tx *ProductService) error {
+		product, err := tx.ProductRepo.LockProduct(productID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrProductNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = tx.ProductRepo.AdjustStockLevel(productID, source.ID, -quantity); err != nil {
+			return err
+		}
+		if err = tx.ProductRepo.AdjustStockLevel(productID, target.ID, quantity); err != nil {
+			return err
+		}
+
+		for _, leg := range []struct {
+			warehouseID uint
+			quantity    int
+		}{{source.ID, -quantity}, {target.ID, quantity}} {
+			err = tx.saveStockMovement(ctx, &models.StockMovement{
+				ProductID:    productID,
+				WarehouseID:  leg.warehouseID,
+				Type:         models.MovementTransfer,
+				Quantity:     leg.quantity,
+				BalanceAfter: product.Quantity,
+				Reference:    reference,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.WarehouseRepo.GetStockLevelsByProduct(productID)
+}
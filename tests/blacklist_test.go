@@ -0,0 +1,305 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"product-controller/service"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func blacklistTestProduct(name string) models.Product {
+	return models.Product{
+		Name:        name,
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	}
+}
+
+func TestBlacklistWordMatchMode(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "bad", MatchMode: models.MatchWord})
+
+	// "bad" jako fragment innego słowa jest dozwolone
+	createTestProduct(t, router, blacklistTestProduct("Badminton"))
+
+	// Granica camelCase wyznacza osobne słowo
+	rr := postJSON(router, "/products", blacklistTestProduct("SuperBadPhone"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "zawiera zabronione słowo: bad")
+	assert.Contains(t, rr.Body.String(), "pozycja 5-8")
+}
+
+func TestBlacklistNormalizedMatchMode(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "bad", MatchMode: models.MatchNormalized})
+
+	// Leetspeak i powtórzone litery
+	rr := postJSON(router, "/products", blacklistTestProduct("B44ddPhone"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "tryb normalized")
+	assert.Contains(t, rr.Body.String(), `fragment "B44dd"`)
+}
+
+func TestBlacklistRegexMatchMode(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: `x{3,}`, MatchMode: models.MatchRegex})
+
+	createTestProduct(t, router, blacklistTestProduct("XxPhone"))
+
+	rr := postJSON(router, "/products", blacklistTestProduct("XXXPhone"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "tryb regex")
+
+	// Niepoprawne wyrażenie albo nieznany tryb są odrzucane przy dodawaniu
+	rr = postJSON(router, "/blacklist", models.BlacklistWord{Word: "(abc", MatchMode: models.MatchRegex})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = postJSON(router, "/blacklist", models.BlacklistWord{Word: "abc", MatchMode: "fuzzy"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestBlacklistMatcherRebuiltAfterChanges(t *testing.T) {
+	router := setupRouter()
+
+	rr := postJSON(router, "/products", blacklistTestProduct("GoodPhone"))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	word := addBlacklistWord(t, router, models.BlacklistWord{Word: "phone"})
+
+	rr = postJSON(router, "/products", blacklistTestProduct("BetterPhone"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ := http.NewRequest("DELETE", "/blacklist/"+strconv.Itoa(int(word.ID)), nil)
+	rrDelete := httptest.NewRecorder()
+	router.ServeHTTP(rrDelete, req)
+	assert.Equal(t, http.StatusNoContent, rrDelete.Code)
+
+	createTestProduct(t, router, blacklistTestProduct("BetterPhone"))
+}
+
+func TestBlacklistFieldAndCategoryScope(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "tani", Fields: models.FieldsDescription})
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "replika", Categories: "Odzież"})
+
+	// Słowo ograniczone do opisu nie blokuje nazwy
+	product := blacklistTestProduct("TaniLaptop")
+	createTestProduct(t, router, product)
+
+	product = blacklistTestProduct("DrogiLaptop")
+	product.Description = "Bardzo tani sprzęt"
+	rr := postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "opis produktu zawiera zabronione słowo: tani")
+
+	// Słowo ograniczone do kategorii nie blokuje innych kategorii
+	product = blacklistTestProduct("Telefon")
+	product.Description = "Replika telefonu"
+	created := createTestProduct(t, router, product)
+
+	// Zmiana kategorii przez PATCH uruchamia reguły nowej kategorii
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(created.ID)), strings.NewReader(`{"Category":"Odzież","Price":100}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", etag(created))
+	rrPatch := httptest.NewRecorder()
+	router.ServeHTTP(rrPatch, req)
+	assert.Equal(t, http.StatusBadRequest, rrPatch.Code)
+	assert.Contains(t, rrPatch.Body.String(), "zabronione słowo: replika")
+}
+
+func waitForScanJob(t *testing.T, router http.Handler, location string) service.ScanJob {
+	var job service.ScanJob
+	for i := 0; i < 100; i++ {
+		req, _ := http.NewRequest("GET", location, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		json.Unmarshal(rr.Body.Bytes(), &job)
+		if job.Status != service.ScanRunning {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return job
+}
+
+func TestBlacklistScanExistingProducts(t *testing.T) {
+	router := setupRouter()
+
+	spam := createTestProduct(t, router, blacklistTestProduct("SpamPhone"))
+	createTestProduct(t, router, blacklistTestProduct("GoodPhone"))
+
+	// Dodanie słowa uruchamia skanowanie, produkt zostaje ukryty
+	rr := postJSON(router, "/blacklist?action=unpublish", models.BlacklistWord{Word: "spam"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("X-Scan-Job"))
+
+	job := waitForScanJob(t, router, rr.Header().Get("X-Scan-Job"))
+	assert.Equal(t, service.ScanCompleted, job.Status)
+	assert.Equal(t, 2, job.Scanned)
+	assert.Equal(t, 1, job.Updated)
+	if assert.Len(t, job.Violations, 1) {
+		assert.Equal(t, spam.ID, job.Violations[0].ProductID)
+		assert.Equal(t, "Spam", job.Violations[0].Fragment)
+	}
+
+	req, _ := http.NewRequest("GET", "/products", nil)
+	rrList := httptest.NewRecorder()
+	router.ServeHTTP(rrList, req)
+
+	var products []models.Product
+	json.Unmarshal(rrList.Body.Bytes(), &products)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "GoodPhone", products[0].Name)
+
+	req, _ = http.NewRequest("GET", "/products?status=unpublished", nil)
+	rrList = httptest.NewRecorder()
+	router.ServeHTTP(rrList, req)
+	json.Unmarshal(rrList.Body.Bytes(), &products)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, spam.ID, products[0].ID)
+		assert.Contains(t, products[0].ModerationNote, "zabronione słowo: spam")
+	}
+
+	// Ukryty produkt nie jest dostępny do sprzedaży
+	rrReserve, _ := reserveTestStock(router, spam.ID, map[string]interface{}{"Quantity": 1})
+	assert.Equal(t, http.StatusConflict, rrReserve.Code)
+	rrSale := postStockMovement(router, spam.ID, map[string]interface{}{"Type": "sale", "Quantity": -1})
+	assert.Equal(t, http.StatusConflict, rrSale.Code)
+
+	// Skanowanie na żądanie tylko raportuje
+	rr = postJSON(router, "/blacklist/scan", nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	job = waitForScanJob(t, router, rr.Header().Get("Location"))
+	assert.Equal(t, service.ScanActionReport, job.Action)
+	assert.Len(t, job.Violations, 1)
+	assert.Equal(t, 0, job.Updated)
+}
+
+func TestAddDuplicateBlacklistWord(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "duplikat"})
+
+	rr := postJSON(router, "/blacklist", models.BlacklistWord{Word: "Duplikat"})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestImportAndExportBlacklist(t *testing.T) {
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "istniejace"})
+
+	csvFile := "word,match_mode,fields,categories\n" +
+		"pierwsze,word,name,\n" +
+		"drugie,normalized,all,\"Elektronika,Odzież\"\n" +
+		"istniejace,,,\n" +
+		"pierwsze,,,\n" +
+		"(zle,regex,,\n"
+	req, _ := http.NewRequest("POST", "/blacklist/import", strings.NewReader(csvFile))
+	req.Header.Set("Content-Type", "text/csv")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var report service.BlacklistImportReport
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 2, report.Duplicates)
+	assert.Equal(t, 1, report.Invalid)
+	if assert.Len(t, report.Results, 5) {
+		assert.Equal(t, 2, report.Results[0].Line)
+		assert.Equal(t, service.ImportCreated, report.Results[0].Status)
+		assert.Equal(t, service.ImportDuplicate, report.Results[2].Status)
+		assert.Equal(t, service.ImportInvalid, report.Results[4].Status)
+	}
+
+	req, _ = http.NewRequest("POST", "/blacklist/import?format=txt", strings.NewReader("# komentarz\ntrzecie\n\nczwarte\n"))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	assert.Equal(t, 2, report.Created)
+
+	req, _ = http.NewRequest("GET", "/blacklist/export?format=json", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var words []models.BlacklistWord
+	json.Unmarshal(rr.Body.Bytes(), &words)
+	assert.Len(t, words, 5)
+
+	req, _ = http.NewRequest("GET", "/blacklist/export?format=csv", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "drugie,normalized,all,\"Elektronika,Odzież\"")
+}
+
+func TestBlacklistHistoryAndRestore(t *testing.T) {
+	router := setupRouter()
+
+	req, _ := http.NewRequest("POST", "/blacklist", strings.NewReader(`{"Word":"zakazane"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "alice")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var word models.BlacklistWord
+	json.Unmarshal(rr.Body.Bytes(), &word)
+
+	req, _ = http.NewRequest("DELETE", "/blacklist/"+strconv.Itoa(int(word.ID)), nil)
+	req.Header.Set("X-Actor", "bob")
+	req.Header.Set("X-Change-Reason", "fałszywe alarmy")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	createTestProduct(t, router, blacklistTestProduct("ZakazaneLaptop"))
+
+	// Usunięte słowo nie może być dodane ponownie, tylko przywrócone
+	rr = postJSON(router, "/blacklist", models.BlacklistWord{Word: "zakazane"})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "przywróć")
+
+	req, _ = http.NewRequest("POST", "/blacklist/"+strconv.Itoa(int(word.ID))+"/restore", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = postJSON(router, "/products", blacklistTestProduct("ZakazaneTelefon"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/blacklist/history?word_id="+strconv.Itoa(int(word.ID)), nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var history []models.BlacklistHistory
+	json.Unmarshal(rr.Body.Bytes(), &history)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, models.BlacklistRestored, history[0].Action)
+		assert.Equal(t, models.BlacklistDeleted, history[1].Action)
+		assert.Equal(t, "bob", history[1].Actor)
+		assert.Equal(t, "fałszywe alarmy", history[1].Reason)
+		assert.Equal(t, models.BlacklistAdded, history[2].Action)
+		assert.Equal(t, "alice", history[2].Actor)
+	}
+}
@@ -0,0 +1,120 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAllCategories(t *testing.T) {
+	router := setupRouter()
+
+	req, _ := http.NewRequest("GET", "/categories", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var categories []models.Category
+	json.Unmarshal(rr.Body.Bytes(), &categories)
+	assert.Len(t, categories, 3)
+}
+
+func TestCreateCategoryAndProductInIt(t *testing.T) {
+	router := setupRouter()
+
+	category := models.Category{
+		Name:     "Zabawki",
+		MinPrice: 20,
+		MaxPrice: 200,
+	}
+	body, _ := json.Marshal(category)
+	req, _ := http.NewRequest("POST", "/categories", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	product := models.Product{
+		Name:        "DrogiMis",
+		Category:    "zabawki",
+		Description: "Za droga zabawka",
+		Price:       300.0,
+		Quantity:    1,
+	}
+	bodyProduct, _ := json.Marshal(product)
+	reqProduct, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(bodyProduct))
+	reqProduct.Header.Set("Content-Type", "application/json")
+	rrProduct := httptest.NewRecorder()
+
+	router.ServeHTTP(rrProduct, reqProduct)
+
+	assert.Equal(t, http.StatusBadRequest, rrProduct.Code)
+	assert.Contains(t, rrProduct.Body.String(), "cena produktu w kategorii Zabawki")
+}
+
+func TestCreateCategoryWithInvalidBounds(t *testing.T) {
+	router := setupRouter()
+
+	category := models.Category{
+		Name:     "Meble",
+		MinPrice: 500,
+		MaxPrice: 100,
+	}
+	body, _ := json.Marshal(category)
+	req, _ := http.NewRequest("POST", "/categories", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestDeleteCategoryInUse(t *testing.T) {
+	router := setupRouter()
+
+	product := models.Product{
+		Name:        "CategoryInUse",
+		Category:    "Odzież",
+		Description: "Opis produktu",
+		Price:       100.0,
+		Quantity:    1,
+	}
+	body, _ := json.Marshal(product)
+	reqCreate, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(body))
+	reqCreate.Header.Set("Content-Type", "application/json")
+	rrCreate := httptest.NewRecorder()
+
+	router.ServeHTTP(rrCreate, reqCreate)
+	assert.Equal(t, http.StatusCreated, rrCreate.Code)
+
+	reqList, _ := http.NewRequest("GET", "/categories", nil)
+	rrList := httptest.NewRecorder()
+	router.ServeHTTP(rrList, reqList)
+
+	var categories []models.Category
+	json.Unmarshal(rrList.Body.Bytes(), &categories)
+
+	var categoryID uint
+	for _, c := range categories {
+		if c.Name == "Odzież" {
+			categoryID = c.ID
+		}
+	}
+
+	reqDelete, _ := http.NewRequest("DELETE", "/categories/"+strconv.Itoa(int(categoryID)), nil)
+	rrDelete := httptest.NewRecorder()
+
+	router.ServeHTTP(rrDelete, reqDelete)
+
+	assert.Equal(t, http.StatusConflict, rrDelete.Code)
+}
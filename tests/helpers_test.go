@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postJSON(router http.Handler, path string, payload interface{}) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(payload)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func createTestProduct(t *testing.T, router http.Handler, product models.Product) models.Product {
+	rr := postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var createdProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &createdProduct)
+	return createdProduct
+}
+
+func etag(product models.Product) string {
+	return `"` + strconv.Itoa(int(product.Version)) + `"`
+}
+
+func addBlacklistWord(t *testing.T, router http.Handler, word models.BlacklistWord) models.BlacklistWord {
+	rr := postJSON(router, "/blacklist", word)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var createdWord models.BlacklistWord
+	json.Unmarshal(rr.Body.Bytes(), &createdWord)
+	return createdWord
+}
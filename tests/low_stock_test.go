@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"product-controller/repository"
+	"product-controller/service"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getLowStockAlerts(router http.Handler, query string) []models.LowStockAlert {
+	req, _ := http.NewRequest("GET", "/alerts/low-stock"+query, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var alerts []models.LowStockAlert
+	json.Unmarshal(rr.Body.Bytes(), &alerts)
+	return alerts
+}
+
+func acknowledgeLowStockAlert(router http.Handler, id uint) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/alerts/low-stock/"+strconv.Itoa(int(id))+"/acknowledge", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func reorderTestProduct(t *testing.T, router http.Handler, name string, quantity, reorderPoint int) models.Product {
+	product := blacklistTestProduct(name)
+	product.Quantity = quantity
+	product.ReorderPoint = reorderPoint
+	product.ReorderQuantity = 20
+	return createTestProduct(t, router, product)
+}
+
+func TestReorderValidation(t *testing.T) {
+	router := setupRouter()
+
+	product := blacklistTestProduct("ReorderInvalid")
+	product.ReorderPoint = -1
+	rr := postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// Próg bez ilości zamówienia nie ma sensu
+	product.ReorderPoint = 5
+	rr = postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestLowStockAlertRaisedOnceWhenThresholdCrossed(t *testing.T) {
+	router := setupRouter()
+
+	product := reorderTestProduct(t, router, "ReorderProduct", 10, 5)
+	assert.Empty(t, getLowStockAlerts(router, ""))
+
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -4})
+	assert.Empty(t, getLowStockAlerts(router, ""))
+
+	// Zejście do progu tworzy alert, kolejne sprzedaże poniżej progu już nie
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -1})
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -2})
+
+	alerts := getLowStockAlerts(router, "?product_id="+strconv.Itoa(int(product.ID)))
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, models.AlertOpen, alerts[0].Status)
+		assert.Equal(t, 5, alerts[0].Quantity)
+		assert.Equal(t, 5, alerts[0].ReorderPoint)
+		assert.Equal(t, 20, alerts[0].ReorderQuantity)
+	}
+
+	rr := acknowledgeLowStockAlert(router, alerts[0].ID)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	rr = acknowledgeLowStockAlert(router, alerts[0].ID)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	rr = acknowledgeLowStockAlert(router, alerts[0].ID+100)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	assert.Empty(t, getLowStockAlerts(router, "?status=open"))
+	assert.Len(t, getLowStockAlerts(router, "?status=acknowledged"), 1)
+
+	// Po uzupełnieniu stanu ponowne zejście do progu daje nowy alert
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "receipt", "Quantity": 20})
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -20})
+	assert.Len(t, getLowStockAlerts(router, "?status=open"), 1)
+}
+
+func TestLowStockAlertOnProductUpdate(t *testing.T) {
+	router := setupRouter()
+
+	product := reorderTestProduct(t, router, "ReorderUpdate", 8, 3)
+
+	product.Quantity = 2
+	body, _ := json.Marshal(product)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(product.ID)), bytes.NewBuffer(body))
+	req.Header.Set("If-Match", etag(product))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	alerts := getLowStockAlerts(router, "")
+	if assert.Len(t, alerts, 1) {
+		assert.Equal(t, product.ID, alerts[0].ProductID)
+		assert.Equal(t, 2, alerts[0].Quantity)
+	}
+
+	rr = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/alerts/low-stock?status=closed", nil)
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestWebhookNotifierDeliversAlerts(t *testing.T) {
+	router := setupRouter()
+
+	var received []models.LowStockAlert
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert models.LowStockAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		received = append(received, alert)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer stub.Close()
+
+	product := reorderTestProduct(t, router, "WebhookProduct", 4, 5)
+
+	alertService := service.NewAlertService(repository.NewProductRepository(), service.NewWebhookNotifier(stub.URL))
+	delivered, err := alertService.DispatchLowStockAlerts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, product.ID, received[0].ProductID)
+		assert.Equal(t, "WebhookProduct", received[0].ProductName)
+	}
+
+	alerts := getLowStockAlerts(router, "")
+	if assert.Len(t, alerts, 1) {
+		assert.NotNil(t, alerts[0].NotifiedAt)
+		assert.Equal(t, 1, alerts[0].NotifyAttempts)
+	}
+
+	// Dostarczony alert nie jest wysyłany ponownie
+	delivered, err = alertService.DispatchLowStockAlerts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, received, 1)
+}
+
+func TestWebhookNotifierFailureIsRetried(t *testing.T) {
+	router := setupRouter()
+
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer stub.Close()
+
+	reorderTestProduct(t, router, "WebhookFailure", 1, 2)
+
+	alertService := service.NewAlertService(repository.NewProductRepository(), service.NewWebhookNotifier(stub.URL))
+	for i := 0; i < 2; i++ {
+		delivered, err := alertService.DispatchLowStockAlerts(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 0, delivered)
+	}
+
+	alerts := getLowStockAlerts(router, "")
+	if assert.Len(t, alerts, 1) {
+		assert.Nil(t, alerts[0].NotifiedAt)
+		assert.Equal(t, 2, alerts[0].NotifyAttempts)
+		assert.Contains(t, alerts[0].NotifyError, "500")
+	}
+}
+
+func TestLogNotifierWritesAlert(t *testing.T) {
+	var buf bytes.Buffer
+	notifier := service.NewLogNotifier(log.New(&buf, "", 0))
+
+	err := notifier.NotifyLowStock(context.Background(), models.LowStockAlert{ProductID: 7, ProductName: "Śrubki", Quantity: 2, ReorderPoint: 5, ReorderQuantity: 50})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "Śrubki")
+	assert.Contains(t, buf.String(), "zamów 50")
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/config"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestGetProductReturnsETag(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "ETagProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID)), nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+}
+
+func TestUpdateProductWithStaleETag(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "StaleETagProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	update := func(price float64) *httptest.ResponseRecorder {
+		product := createdProduct
+		product.Price = price
+		body, _ := json.Marshal(product)
+		req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag(createdProduct))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := update(600.0)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, `"2"`, first.Header().Get("ETag"))
+
+	// Druga zmiana wciąż opiera się na wersji 1
+	second := update(700.0)
+	assert.Equal(t, http.StatusPreconditionFailed, second.Code)
+
+	// Odrzucona zmiana nie może zostawić śladu w historii
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+	for _, h := range history {
+		assert.NotEqual(t, "700.00", h.NewValue)
+	}
+}
+
+func TestUpdateProductConflictInsideTransaction(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "RacedProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	var historyBefore int64
+	config.DB.Model(&models.ProductHistory{}).Where("product_id = ?", createdProduct.ID).Count(&historyBefore)
+	var headBefore models.ProductHistoryHead
+	config.DB.First(&headBefore, "product_id = ?", createdProduct.ID)
+
+	// Równoległa zmiana tuż przed zapisem: wersja sprawdzona na początku już nie obowiązuje,
+	// więc warunkowy UPDATE w transakcji nie trafia w żaden wiersz
+	raced := false
+	config.DB.Callback().Update().Before("gorm:update").Register("test:concurrent_edit", func(db *gorm.DB) {
+		if db.Statement.Table == "products" && !raced {
+			raced = true
+			config.DB.Exec("UPDATE products SET version = version + 1 WHERE id = ?", createdProduct.ID)
+		}
+	})
+	defer config.DB.Callback().Update().Remove("test:concurrent_edit")
+
+	product := createdProduct
+	product.Price = 700.0
+	body, _ := json.Marshal(product)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.True(t, raced)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+
+	// Wycofana transakcja nie zostawia wpisu historii ani nie przesuwa końca łańcucha
+	var historyAfter int64
+	config.DB.Model(&models.ProductHistory{}).Where("product_id = ?", createdProduct.ID).Count(&historyAfter)
+	assert.Equal(t, historyBefore, historyAfter)
+
+	var headAfter models.ProductHistoryHead
+	config.DB.First(&headAfter, "product_id = ?", createdProduct.ID)
+	assert.Equal(t, headBefore.HistoryID, headAfter.HistoryID)
+	assert.Equal(t, headBefore.Hash, headAfter.Hash)
+}
+
+func TestDeleteProductWithoutIfMatch(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "NoIfMatchProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("DELETE", "/products/"+strconv.Itoa(int(createdProduct.ID)), nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, rr.Code)
+}
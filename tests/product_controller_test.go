@@ -11,38 +11,100 @@ import (
 	"product-controller/repository"
 	"product-controller/service"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 )
 
 func setupRouter() http.Handler {
 	config.InitDB()
+	config.MigrateDB()
 	truncateTables()
 
 	productRepo := repository.NewProductRepository()
 	blacklistRepo := repository.NewBlacklistRepository()
-
-	productService := service.NewProductService(productRepo, blacklistRepo)
+	categoryRepo := repository.NewCategoryRepository()
+	warehouseRepo := repository.NewWarehouseRepository()
+	blacklistService := service.NewBlacklistService(blacklistRepo)
+	categoryRepo.EnsureDefaultCategories()
+	warehouseRepo.EnsureDefaultWarehouse()
+	productRepo.EnsureProductHistoryHeads()
+
+	productService := service.NewProductService(productRepo, blacklistService, categoryRepo, warehouseRepo)
+	productService.BlacklistMode, _ = service.ParseBlacklistMode(config.BlacklistMode())
+	categoryService := service.NewCategoryService(categoryRepo)
+	warehouseService := service.NewWarehouseService(warehouseRepo)
+	alertService := service.NewAlertService(productRepo, service.NewLogNotifier(nil))
+	scanService := service.NewBlacklistScanService(productService)
 
 	productController := controller.NewProductController(productService)
-	blacklistController := controller.NewBlacklistController(blacklistRepo)
+	blacklistController := controller.NewBlacklistController(blacklistService, scanService)
+	categoryController := controller.NewCategoryController(categoryService)
+	warehouseController := controller.NewWarehouseController(warehouseService)
+	alertController := controller.NewAlertController(alertService)
 
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(controller.AuditContext)
 
 	// Product routes
 	r.Get("/products", productController.GetAllProducts)
+	r.Get("/products/trash", productController.GetDeletedProducts)
+	r.Post("/products/import", productController.ImportProducts)
+	r.Get("/products/export", productController.ExportProducts)
+	r.Post("/products/{id}/restore", productController.RestoreProduct)
+	r.Delete("/products/{id}/purge", productController.PurgeProduct)
 	r.Get("/products/{id}", productController.GetProductByID)
 	r.Post("/products", productController.AddProduct)
 	r.Put("/products/{id}", productController.UpdateProduct)
+	r.Patch("/products/{id}", productController.PatchProduct)
 	r.Delete("/products/{id}", productController.DeleteProduct)
 	r.Get("/products/{id}/history", productController.GetProductHistory)
+	r.Post("/products/{id}/revert", productController.RevertProduct)
+	r.Post("/products/{id}/approve", productController.ApproveProduct)
+	r.Post("/products/{id}/reject", productController.RejectProduct)
+	r.Get("/products/{id}/as-of", productController.GetProductAsOf)
+	r.Get("/products/{id}/diff", productController.GetProductDiff)
+	r.Post("/products/{id}/stock-movements", productController.PostStockMovement)
+	r.Get("/products/{id}/stock-movements", productController.GetStockMovements)
+	r.Post("/products/{id}/reservations", productController.ReserveStock)
+	r.Get("/products/{id}/reservations", productController.GetReservations)
+	r.Post("/products/{id}/reservations/{reservationId}/confirm", productController.ConfirmReservation)
+	r.Post("/products/{id}/reservations/{reservationId}/release", productController.ReleaseReservation)
+	r.Get("/products/{id}/stock", productController.GetProductStock)
+	r.Post("/products/{id}/transfers", productController.TransferStock)
+	r.Get("/history", productController.GetHistory)
+	r.Get("/history/verify", productController.VerifyHistory)
 
 	// Blacklist routes
 	r.Get("/blacklist", blacklistController.GetAllBlacklistWords)
 	r.Post("/blacklist", blacklistController.AddBlacklistWord)
 	r.Delete("/blacklist/{id}", blacklistController.DeleteBlacklistWord)
+	r.Post("/blacklist/{id}/restore", blacklistController.RestoreBlacklistWord)
+	r.Get("/blacklist/history", blacklistController.GetBlacklistHistory)
+	r.Post("/blacklist/import", blacklistController.ImportBlacklistWords)
+	r.Get("/blacklist/export", blacklistController.ExportBlacklistWords)
+	r.Post("/blacklist/scan", blacklistController.StartScan)
+	r.Get("/blacklist/scan/{id}", blacklistController.GetScanJob)
+
+	// Category routes
+	r.Get("/categories", categoryController.GetAllCategories)
+	r.Post("/categories", categoryController.AddCategory)
+	r.Get("/categories/{id}", categoryController.GetCategoryByID)
+	r.Put("/categories/{id}", categoryController.UpdateCategory)
+	r.Delete("/categories/{id}", categoryController.DeleteCategory)
+
+	// Warehouse routes
+	r.Get("/warehouses", warehouseController.GetAllWarehouses)
+	r.Post("/warehouses", warehouseController.AddWarehouse)
+	r.Get("/warehouses/{id}/stock", warehouseController.GetWarehouseStock)
+
+	// Alert routes
+	r.Get("/alerts/low-stock", alertController.GetLowStockAlerts)
+	r.Post("/alerts/low-stock/{id}/acknowledge", alertController.AcknowledgeLowStockAlert)
 
 	return r
 }
@@ -147,6 +209,7 @@ func TestUpdateProductAndCheckHistory(t *testing.T) {
 	bodyUpdate, _ := json.Marshal(updatedProduct)
 	reqUpdate, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBuffer(bodyUpdate))
 	reqUpdate.Header.Set("Content-Type", "application/json")
+	reqUpdate.Header.Set("If-Match", rrCreate.Header().Get("ETag"))
 	rrUpdate := httptest.NewRecorder()
 
 	router.ServeHTTP(rrUpdate, reqUpdate)
@@ -164,6 +227,52 @@ func TestUpdateProductAndCheckHistory(t *testing.T) {
 	assert.True(t, len(history) > 0)
 }
 
+func TestCreateProductAndUpdateCategoryHistory(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "CategoryHistory",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       100.0,
+		Quantity:    2,
+	})
+
+	updatedProduct := createdProduct
+	updatedProduct.Category = "Odzież"
+	bodyUpdate, _ := json.Marshal(updatedProduct)
+	reqUpdate, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBuffer(bodyUpdate))
+	reqUpdate.Header.Set("Content-Type", "application/json")
+	reqUpdate.Header.Set("If-Match", etag(createdProduct))
+	rrUpdate := httptest.NewRecorder()
+
+	router.ServeHTTP(rrUpdate, reqUpdate)
+	assert.Equal(t, http.StatusOK, rrUpdate.Code)
+
+	var savedProduct models.Product
+	json.Unmarshal(rrUpdate.Body.Bytes(), &savedProduct)
+	assert.Equal(t, "Odzież", savedProduct.Category)
+
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+
+	var created, categoryChanged bool
+	for _, h := range history {
+		if h.Field == "Name" && h.OldValue == "" && h.NewValue == "CategoryHistory" {
+			created = true
+		}
+		if h.Field == "Category" && h.OldValue == "Elektronika" && h.NewValue == "Odzież" {
+			categoryChanged = true
+		}
+	}
+	assert.True(t, created)
+	assert.True(t, categoryChanged)
+}
+
 func TestDeleteProduct(t *testing.T) {
 	router := setupRouter()
 
@@ -186,12 +295,98 @@ func TestDeleteProduct(t *testing.T) {
 	json.Unmarshal(rrCreate.Body.Bytes(), &createdProduct)
 
 	reqDelete, _ := http.NewRequest("DELETE", "/products/"+strconv.Itoa(int(createdProduct.ID)), nil)
+	reqDelete.Header.Set("If-Match", rrCreate.Header().Get("ETag"))
 	rrDelete := httptest.NewRecorder()
 
 	router.ServeHTTP(rrDelete, reqDelete)
 	assert.Equal(t, http.StatusNoContent, rrDelete.Code)
 }
 
+func TestGetAllProductsWithFilterAndPagination(t *testing.T) {
+	router := setupRouter()
+
+	for i, price := range []float64{100.0, 200.0, 300.0} {
+		product := models.Product{
+			Name:        "PageProduct" + strconv.Itoa(i),
+			Category:    "Elektronika",
+			Description: "Opis produktu",
+			Price:       price,
+			Quantity:    1,
+		}
+		body, _ := json.Marshal(product)
+		req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	}
+
+	req, _ := http.NewRequest("GET", "/products?min_price=150&sort=-price&limit=1", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("X-Total-Count"))
+	assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
+
+	var products []models.Product
+	json.Unmarshal(rr.Body.Bytes(), &products)
+	assert.Len(t, products, 1)
+	assert.Equal(t, 300.0, products[0].Price)
+}
+
+func TestGetAllProductsWithCursor(t *testing.T) {
+	router := setupRouter()
+
+	for i := 0; i < 3; i++ {
+		product := models.Product{
+			Name:        "CursorProduct" + strconv.Itoa(i),
+			Category:    "Elektronika",
+			Description: "Opis produktu",
+			Price:       100.0,
+			Quantity:    1,
+		}
+		body, _ := json.Marshal(product)
+		req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(body))
+		req.Header.Set("Content-Type", "application/json")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusCreated, rr.Code)
+	}
+
+	req, _ := http.NewRequest("GET", "/products?limit=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	link := rr.Header().Get("Link")
+	assert.Contains(t, link, "cursor=")
+
+	next := link[strings.Index(link, "<")+1 : strings.Index(link, ">")]
+	reqNext, _ := http.NewRequest("GET", next, nil)
+	rrNext := httptest.NewRecorder()
+	router.ServeHTTP(rrNext, reqNext)
+	assert.Equal(t, http.StatusOK, rrNext.Code)
+
+	var products []models.Product
+	json.Unmarshal(rrNext.Body.Bytes(), &products)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "CursorProduct2", products[0].Name)
+	assert.Contains(t, rrNext.Header().Get("Link"), `rel="prev"`)
+}
+
+func TestGetAllProductsWithInvalidSort(t *testing.T) {
+	router := setupRouter()
+
+	req, _ := http.NewRequest("GET", "/products?sort=password", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
 /////////////////////////////////////////////////////
 //                 Walidacje                      //
 /////////////////////////////////////////////////////
@@ -382,6 +577,14 @@ func truncateTables() {
 	db.Exec("SET FOREIGN_KEY_CHECKS = 0;")
 	db.Exec("TRUNCATE TABLE products;")
 	db.Exec("TRUNCATE TABLE product_histories;")
+	db.Exec("TRUNCATE TABLE product_history_heads;")
 	db.Exec("TRUNCATE TABLE blacklist_words;")
+	db.Exec("TRUNCATE TABLE blacklist_histories;")
+	db.Exec("TRUNCATE TABLE stock_movements;")
+	db.Exec("TRUNCATE TABLE stock_reservations;")
+	db.Exec("TRUNCATE TABLE stock_levels;")
+	db.Exec("TRUNCATE TABLE warehouses;")
+	db.Exec("TRUNCATE TABLE low_stock_alerts;")
+	db.Exec("TRUNCATE TABLE categories;")
 	db.Exec("SET FOREIGN_KEY_CHECKS = 1;")
 }
@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func exportTestProducts(router http.Handler, query, accept string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", "/products/export"+query, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestExportProducts(t *testing.T) {
+	router := setupRouter()
+
+	createTestProduct(t, router, blacklistTestProduct("ExportLaptop"))
+	createTestProduct(t, router, blacklistTestProduct("ExportTelefon"))
+	book := blacklistTestProduct("ExportKsiazka")
+	book.Category = "Książki"
+	book.Price = 50.0
+	createTestProduct(t, router, book)
+
+	// CSV z tymi samymi filtrami co lista produktów
+	rr := exportTestProducts(router, "?category=Elektronika", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/csv")
+
+	records, err := csv.NewReader(strings.NewReader(rr.Body.String())).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "Name", records[0][1])
+		assert.Equal(t, "ExportLaptop", records[1][1])
+		assert.Equal(t, "500.00", records[1][4])
+	}
+
+	// Format wybrany nagłówkiem Accept
+	rr = exportTestProducts(router, "", "application/x-ndjson")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var products []models.Product
+	scanner := bufio.NewScanner(strings.NewReader(rr.Body.String()))
+	for scanner.Scan() {
+		var product models.Product
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &product))
+		products = append(products, product)
+	}
+	assert.Len(t, products, 3)
+
+	rr = exportTestProducts(router, "?format=xlsx", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Body.String(), "PK"))
+
+	rr = exportTestProducts(router, "?format=pdf", "")
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+}
+
+func TestExportCSVEscapesFormulas(t *testing.T) {
+	router := setupRouter()
+
+	product := blacklistTestProduct("ExportFormula")
+	product.Description = "=HYPERLINK(\"http://example.com\")"
+	createTestProduct(t, router, product)
+	discount := blacklistTestProduct("ExportDiscount")
+	discount.Description = "-50% taniej"
+	createTestProduct(t, router, discount)
+
+	rr := exportTestProducts(router, "", "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	records, err := csv.NewReader(strings.NewReader(rr.Body.String())).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		// Komórki zaczynające się od =, +, - lub @ nie są wykonywane jako formuły
+		assert.Equal(t, "'=HYPERLINK(\"http://example.com\")", records[1][3])
+		assert.Equal(t, "'-50% taniej", records[2][3])
+		assert.Equal(t, "500.00", records[1][4])
+	}
+}
@@ -0,0 +1,402 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"product-controller/config"
+	"product-controller/models"
+	"product-controller/service"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryContainsActorAndReason(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "AuditedProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	body := `{"Name": "AuditedProduct", "Category": "Elektronika", "Description": "Opis produktu", "Price": 550, "Quantity": 1, "Reason": "promocja"}`
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	req.Header.Set("X-Actor", "jan.kowalski")
+	req.Header.Set("X-Request-Id", "req-123")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.RemoteAddr = "10.0.0.5:51234"
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+
+	var found bool
+	for _, h := range history {
+		if h.Field == "Price" && h.NewValue == "550.00" {
+			found = true
+			assert.Equal(t, "jan.kowalski", h.Actor)
+			assert.Equal(t, "req-123", h.RequestID)
+			assert.Equal(t, "promocja", h.Reason)
+			assert.Equal(t, "10.0.0.5", h.SourceIP)
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestHistoryReasonFromHeader(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "ReasonHeader",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("DELETE", "/products/"+strconv.Itoa(int(createdProduct.ID)), nil)
+	req.Header.Set("If-Match", etag(createdProduct))
+	req.Header.Set("X-API-Client", "sklep")
+	req.Header.Set("X-Change-Reason", "wycofany")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+
+	for _, h := range history {
+		if h.Field == "Deleted" {
+			assert.Equal(t, "sklep", h.Actor)
+			assert.Equal(t, "wycofany", h.Reason)
+		}
+	}
+}
+
+func updateTestProduct(t *testing.T, router http.Handler, product models.Product) models.Product {
+	body, _ := json.Marshal(product)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(product.ID)), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag(product))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var updatedProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &updatedProduct)
+	return updatedProduct
+}
+
+func getTestProductHistory(router http.Handler, id uint) []models.ProductHistory {
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(id))+"/history", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rr.Body.Bytes(), &history)
+	return history
+}
+
+func TestRevertProductToHistoryEntry(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, models.Product{
+		Name:        "RevertProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	product.Price = 600.0
+	product = updateTestProduct(t, router, product)
+
+	product.Price = 700.0
+	product.Description = "Nowy opis"
+	product = updateTestProduct(t, router, product)
+
+	var historyID uint
+	for _, h := range getTestProductHistory(router, product.ID) {
+		if h.Field == "Price" && h.NewValue == "600.00" {
+			historyID = h.ID
+		}
+	}
+	assert.NotZero(t, historyID)
+
+	rr := postJSON(router, "/products/"+strconv.Itoa(int(product.ID))+"/revert", map[string]interface{}{"HistoryID": historyID})
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var revertedProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &revertedProduct)
+	assert.Equal(t, 600.0, revertedProduct.Price)
+	assert.Equal(t, "Opis produktu", revertedProduct.Description)
+
+	var revertEntries int
+	for _, h := range getTestProductHistory(router, product.ID) {
+		if h.Field == "Price" && h.OldValue == "700.00" && h.NewValue == "600.00" {
+			revertEntries++
+			assert.Contains(t, h.Reason, "przywrócenie")
+		}
+	}
+	assert.Equal(t, 1, revertEntries)
+}
+
+func TestRevertProductRequiresPoint(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, models.Product{
+		Name:        "RevertNoPoint",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	rr := postJSON(router, "/products/"+strconv.Itoa(int(product.ID))+"/revert", map[string]interface{}{})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestProductAsOfAndDiff(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, models.Product{
+		Name:        "AsOfProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	time.Sleep(20 * time.Millisecond)
+	beforeUpdate := time.Now()
+	time.Sleep(20 * time.Millisecond)
+
+	product.Price = 800.0
+	product.Quantity = 4
+	product = updateTestProduct(t, router, product)
+
+	base := "/products/" + strconv.Itoa(int(product.ID))
+	at := url.QueryEscape(beforeUpdate.Format(time.RFC3339Nano))
+
+	reqAsOf, _ := http.NewRequest("GET", base+"/as-of?t="+at, nil)
+	rrAsOf := httptest.NewRecorder()
+	router.ServeHTTP(rrAsOf, reqAsOf)
+	assert.Equal(t, http.StatusOK, rrAsOf.Code)
+
+	var snapshot service.ProductSnapshot
+	json.Unmarshal(rrAsOf.Body.Bytes(), &snapshot)
+	assert.Equal(t, 500.0, snapshot.Price)
+	assert.Equal(t, 1, snapshot.Quantity)
+
+	now := url.QueryEscape(time.Now().Format(time.RFC3339Nano))
+	reqDiff, _ := http.NewRequest("GET", base+"/diff?from="+at+"&to="+now, nil)
+	rrDiff := httptest.NewRecorder()
+	router.ServeHTTP(rrDiff, reqDiff)
+	assert.Equal(t, http.StatusOK, rrDiff.Code)
+
+	var diff service.ProductDiff
+	json.Unmarshal(rrDiff.Body.Bytes(), &diff)
+	assert.Equal(t, []service.FieldDiff{
+		{Field: "Price", From: "500.00", To: "800.00"},
+		{Field: "Quantity", From: "1", To: "4"},
+	}, diff.Changes)
+}
+
+func TestProductAsOfBeforeCreation(t *testing.T) {
+	router := setupRouter()
+
+	before := url.QueryEscape(time.Now().Add(-time.Hour).Format(time.RFC3339))
+	product := createTestProduct(t, router, models.Product{
+		Name:        "AsOfTooEarly",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(product.ID))+"/as-of?t="+before, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestProductHistoryFilterAndCursor(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, models.Product{
+		Name:        "PagedHistory",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+	for _, price := range []float64{510.0, 520.0, 530.0} {
+		product.Price = price
+		product = updateTestProduct(t, router, product)
+	}
+
+	base := "/products/" + strconv.Itoa(int(product.ID)) + "/history"
+	req, _ := http.NewRequest("GET", base+"?field=Price&order=desc&limit=2", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "4", rr.Header().Get("X-Total-Count"))
+
+	var history []models.ProductHistory
+	json.Unmarshal(rr.Body.Bytes(), &history)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "530.00", history[0].NewValue)
+	assert.Equal(t, "520.00", history[1].NewValue)
+
+	link := rr.Header().Get("Link")
+	next := link[strings.Index(link, "<")+1 : strings.Index(link, ">")]
+	reqNext, _ := http.NewRequest("GET", next, nil)
+	rrNext := httptest.NewRecorder()
+	router.ServeHTTP(rrNext, reqNext)
+
+	var nextHistory []models.ProductHistory
+	json.Unmarshal(rrNext.Body.Bytes(), &nextHistory)
+	assert.Len(t, nextHistory, 2)
+	assert.Equal(t, "510.00", nextHistory[0].NewValue)
+	assert.Empty(t, rrNext.Header().Get("Link"))
+}
+
+func TestGlobalHistoryByActor(t *testing.T) {
+	router := setupRouter()
+
+	createTestProduct(t, router, models.Product{
+		Name:        "GlobalHistoryA",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+
+	body, _ := json.Marshal(models.Product{
+		Name:        "GlobalHistoryB",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+	req, _ := http.NewRequest("POST", "/products", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "importer")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	reqHistory, _ := http.NewRequest("GET", "/history?actor=importer", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+	assert.Equal(t, http.StatusOK, rrHistory.Code)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+	assert.NotEmpty(t, history)
+	for _, h := range history {
+		assert.Equal(t, "importer", h.Actor)
+	}
+}
+
+func TestVerifyHistoryChain(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, models.Product{
+		Name:        "ChainedProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	})
+	product.Price = 600.0
+	product = updateTestProduct(t, router, product)
+
+	verify := func() service.ChainReport {
+		req, _ := http.NewRequest("GET", "/history/verify?product_id="+strconv.Itoa(int(product.ID)), nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+
+		var report service.ChainReport
+		json.Unmarshal(rr.Body.Bytes(), &report)
+		return report
+	}
+
+	report := verify()
+	assert.True(t, report.Valid)
+	assert.Equal(t, 6, report.Checked)
+
+	// Ręczna zmiana wpisu w bazie musi zostać wykryta
+	var tampered models.ProductHistory
+	config.DB.Where("product_id = ? AND field = ?", product.ID, "Price").Order("id DESC").First(&tampered)
+	config.DB.Model(&tampered).Update("new_value", "1.00")
+
+	report = verify()
+	assert.False(t, report.Valid)
+	assert.Equal(t, tampered.ID, report.Break.HistoryID)
+}
+
+func TestVerifyHistoryChainDetectsDeletedEntries(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, blacklistTestProduct("TruncatedChain"))
+	product.Price = 600.0
+	product = updateTestProduct(t, router, product)
+
+	verify := func() service.ChainReport {
+		req, _ := http.NewRequest("GET", "/history/verify", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		var report service.ChainReport
+		json.Unmarshal(rr.Body.Bytes(), &report)
+		return report
+	}
+	assert.True(t, verify().Valid)
+
+	// Usunięcie najnowszego wpisu zostawia spójny, ale krótszy łańcuch
+	var latest models.ProductHistory
+	config.DB.Where("product_id = ?", product.ID).Order("id DESC").First(&latest)
+	config.DB.Delete(&latest)
+
+	report := verify()
+	assert.False(t, report.Valid)
+	if assert.NotNil(t, report.Break) {
+		assert.Equal(t, product.ID, report.Break.ProductID)
+	}
+
+	// Usunięcie całej historii produktu również
+	config.DB.Where("product_id = ?", product.ID).Delete(&models.ProductHistory{})
+
+	report = verify()
+	assert.False(t, report.Valid)
+	if assert.NotNil(t, report.Break) {
+		assert.Equal(t, product.ID, report.Break.ProductID)
+	}
+}
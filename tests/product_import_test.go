@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"product-controller/service"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func importTestProducts(router http.Handler, contentType, mode, body string) (*httptest.ResponseRecorder, service.ProductImportReport) {
+	req, _ := http.NewRequest("POST", "/products/import?mode="+mode, strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var report service.ProductImportReport
+	json.Unmarshal(rr.Body.Bytes(), &report)
+	return rr, report
+}
+
+func countTestProducts(router http.Handler) int {
+	req, _ := http.NewRequest("GET", "/products?status=all", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var products []models.Product
+	json.Unmarshal(rr.Body.Bytes(), &products)
+	return len(products)
+}
+
+const importTestCSV = "name,category,description,price,quantity\n" +
+	"ImportLaptop,Elektronika,Laptop,2500,3\n" +
+	"ImportKsiazka,Książki,Powieść,40,10\n" +
+	"ImportBluza,Odzież,Bluza,1,5\n" +
+	"Istniejacy,Elektronika,Nowy opis,600,2\n" +
+	"Bezzmian,Elektronika,Opis produktu,500,1\n"
+
+func TestImportProductsBestEffort(t *testing.T) {
+	router := setupRouter()
+
+	createTestProduct(t, router, blacklistTestProduct("Istniejacy"))
+	createTestProduct(t, router, blacklistTestProduct("Bezzmian"))
+
+	rr, report := importTestProducts(router, "text/csv", service.ImportBestEffort, importTestCSV)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, report.Committed)
+	assert.Equal(t, 2, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.Failed)
+	if assert.Len(t, report.Rows, 5) {
+		assert.Equal(t, 4, report.Rows[2].Line)
+		assert.Equal(t, service.ProductImportFailed, report.Rows[2].Status)
+		assert.Contains(t, report.Rows[2].Error, "cena produktu w kategorii Odzież")
+	}
+	assert.Equal(t, 4, countTestProducts(router))
+}
+
+func TestImportProductsAtomicAndDryRun(t *testing.T) {
+	router := setupRouter()
+
+	createTestProduct(t, router, blacklistTestProduct("Istniejacy"))
+	createTestProduct(t, router, blacklistTestProduct("Bezzmian"))
+
+	// Jeden błędny wiersz wycofuje cały import
+	rr, report := importTestProducts(router, "text/csv", service.ImportAtomic, importTestCSV)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.False(t, report.Committed)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 2, countTestProducts(router))
+
+	ndjson := `{"Name":"NowyProdukt","Category":"Elektronika","Price":100,"Quantity":1}` + "\n" +
+		`{"Name":"Istniejacy","Quantity":7}` + "\n"
+
+	// Dry-run raportuje zmiany, ale niczego nie zapisuje
+	rr, report = importTestProducts(router, "application/x-ndjson", service.ImportDryRun, ndjson)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.False(t, report.Committed)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 2, countTestProducts(router))
+
+	rr, report = importTestProducts(router, "application/x-ndjson", service.ImportAtomic, ndjson)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, report.Committed)
+	assert.Equal(t, 3, countTestProducts(router))
+}
+
+func TestImportProductNameInTrash(t *testing.T) {
+	router := setupRouter()
+
+	trashed := createTestProduct(t, router, blacklistTestProduct("WKoszu"))
+	deleteTestProduct(t, router, trashed)
+
+	rr, report := importTestProducts(router, "text/csv", service.ImportBestEffort,
+		"name,category,description,price,quantity\nWKoszu,Elektronika,Opis,500,1\n")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, report.Failed)
+	if assert.Len(t, report.Rows, 1) {
+		assert.Equal(t, service.ProductImportTrashed, report.Rows[0].Status)
+		assert.Equal(t, trashed.ID, report.Rows[0].ProductID)
+		assert.Contains(t, report.Rows[0].Error, "w koszu")
+	}
+}
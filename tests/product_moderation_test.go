@@ -0,0 +1,152 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"product-controller/service"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func moderateTestProduct(router http.Handler, product models.Product, decision, reason string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/products/"+strconv.Itoa(int(product.ID))+"/"+decision, strings.NewReader(`{"Reason":"`+reason+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", "moderator")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestQuarantineModeration(t *testing.T) {
+	t.Setenv("BLACKLIST_MODE", service.BlacklistModeQuarantine)
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "kopia"})
+
+	// Naruszenie nie odrzuca produktu, tylko kieruje go do moderacji
+	rr := postJSON(router, "/products", blacklistTestProduct("KopiaZegarka"))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	var pending models.Product
+	json.Unmarshal(rr.Body.Bytes(), &pending)
+	assert.Equal(t, models.StatusPending, pending.Status)
+	assert.Contains(t, pending.ModerationNote, "zabronione słowo: kopia")
+
+	second := postJSON(router, "/products", blacklistTestProduct("KopiaTelefonu"))
+	assert.Equal(t, http.StatusAccepted, second.Code)
+	var rejected models.Product
+	json.Unmarshal(second.Body.Bytes(), &rejected)
+
+	// Produkty oczekujące nie są widoczne na liście
+	req, _ := http.NewRequest("GET", "/products", nil)
+	rrList := httptest.NewRecorder()
+	router.ServeHTTP(rrList, req)
+	var products []models.Product
+	json.Unmarshal(rrList.Body.Bytes(), &products)
+	assert.Len(t, products, 0)
+
+	rr = moderateTestProduct(router, pending, "approve", "fałszywy alarm")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var approved models.Product
+	json.Unmarshal(rr.Body.Bytes(), &approved)
+	assert.Equal(t, models.StatusActive, approved.Status)
+
+	// Zmiana pól niezwiązanych z blacklistą nie cofa zatwierdzonego produktu do moderacji
+	approved.Price = approved.Price + 1
+	approved = updateTestProduct(t, router, approved)
+	assert.Equal(t, models.StatusActive, approved.Status)
+
+	// Produkt w kwarantannie nie jest dostępny pod swoim ID ani do sprzedaży
+	req, _ = http.NewRequest("GET", "/products/"+strconv.Itoa(int(rejected.ID)), nil)
+	rrGet := httptest.NewRecorder()
+	router.ServeHTTP(rrGet, req)
+	assert.Equal(t, http.StatusNotFound, rrGet.Code)
+
+	rr, _ = reserveTestStock(router, rejected.ID, map[string]interface{}{"Quantity": 1})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	rr = postStockMovement(router, rejected.ID, map[string]interface{}{"Type": "sale", "Quantity": -1})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = moderateTestProduct(router, rejected, "reject", "podróbka")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/products/"+strconv.Itoa(int(rejected.ID)), nil)
+	rrGet = httptest.NewRecorder()
+	router.ServeHTTP(rrGet, req)
+	assert.Equal(t, http.StatusNotFound, rrGet.Code)
+
+	// Decyzja zapada tylko raz
+	rr = moderateTestProduct(router, pending, "reject", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Każda decyzja trafia do historii wraz z moderatorem i uzasadnieniem
+	history := getTestProductHistory(router, pending.ID)
+	var decision *models.ProductHistory
+	for i := range history {
+		if history[i].Field == "Status" && history[i].NewValue == models.StatusActive {
+			decision = &history[i]
+		}
+	}
+	if assert.NotNil(t, decision) {
+		assert.Equal(t, models.StatusPending, decision.OldValue)
+		assert.Equal(t, "moderator", decision.Actor)
+		assert.Equal(t, "fałszywy alarm", decision.Reason)
+	}
+
+	rejectedInHistory := false
+	for _, h := range getTestProductHistory(router, rejected.ID) {
+		rejectedInHistory = rejectedInHistory || h.Field == "Status" && h.NewValue == models.StatusRejected
+	}
+	assert.True(t, rejectedInHistory)
+}
+
+func TestRejectModeIsDefault(t *testing.T) {
+	t.Setenv("BLACKLIST_MODE", "")
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "kopia"})
+
+	rr := postJSON(router, "/products", blacklistTestProduct("KopiaZegarka"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestScanDoesNotOverrideRejection(t *testing.T) {
+	t.Setenv("BLACKLIST_MODE", service.BlacklistModeQuarantine)
+	router := setupRouter()
+
+	addBlacklistWord(t, router, models.BlacklistWord{Word: "kopia"})
+
+	rr := postJSON(router, "/products", blacklistTestProduct("KopiaPortfela"))
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	var product models.Product
+	json.Unmarshal(rr.Body.Bytes(), &product)
+
+	rr = moderateTestProduct(router, product, "reject", "podróbka")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = postJSON(router, "/blacklist/scan?action=unpublish", nil)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+	job := waitForScanJob(t, router, rr.Header().Get("Location"))
+	assert.Len(t, job.Violations, 1)
+	assert.Equal(t, 0, job.Updated)
+
+	req, _ := http.NewRequest("GET", "/products?status=rejected", nil)
+	rrList := httptest.NewRecorder()
+	router.ServeHTTP(rrList, req)
+	var products []models.Product
+	json.Unmarshal(rrList.Body.Bytes(), &products)
+	if assert.Len(t, products, 1) {
+		assert.Equal(t, product.ID, products[0].ID)
+		assert.Equal(t, product.ModerationNote, products[0].ModerationNote)
+	}
+
+	// Odrzuconego produktu nie można ponownie zatwierdzić
+	rr = moderateTestProduct(router, product, "approve", "")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
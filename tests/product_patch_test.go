@@ -0,0 +1,159 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatchProduct(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "MergePatchProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    7,
+	})
+
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(`{"Price": 650}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var patchedProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &patchedProduct)
+	assert.Equal(t, 650.0, patchedProduct.Price)
+	assert.Equal(t, "Opis produktu", patchedProduct.Description)
+	assert.Equal(t, 7, patchedProduct.Quantity)
+
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+
+	var priceChanges int
+	for _, h := range history {
+		// Pomijamy wpisy z utworzenia produktu
+		if h.OldValue == "" {
+			continue
+		}
+		assert.NotEqual(t, "Quantity", h.Field)
+		assert.NotEqual(t, "Description", h.Field)
+		if h.Field == "Price" {
+			priceChanges++
+		}
+	}
+	assert.Equal(t, 1, priceChanges)
+}
+
+func TestJSONPatchProduct(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "JSONPatchProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    3,
+	})
+
+	patch := `[
+		{"op": "test", "path": "/Quantity", "value": 3},
+		{"op": "replace", "path": "/Quantity", "value": 4},
+		{"op": "remove", "path": "/Description"}
+	]`
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(patch))
+	req.Header.Set("Content-Type", "application/json-patch+json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var patchedProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &patchedProduct)
+	assert.Equal(t, 4, patchedProduct.Quantity)
+	assert.Equal(t, "", patchedProduct.Description)
+	assert.Equal(t, 500.0, patchedProduct.Price)
+}
+
+func TestPatchProductValidatesMergedResult(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "PatchValidation",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       1000.0,
+		Quantity:    1,
+	})
+
+	// Cena 1000 przekracza limit kategorii Książki
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(`{"Category": "Książki"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cena produktu w kategorii")
+}
+
+func TestPatchProductReadOnlyField(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "PatchReadOnly",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       1000.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(`{"ID": 999}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestPatchProductCategory(t *testing.T) {
+	router := setupRouter()
+
+	createdProduct := createTestProduct(t, router, models.Product{
+		Name:        "PatchCategory",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       100.0,
+		Quantity:    1,
+	})
+
+	req, _ := http.NewRequest("PATCH", "/products/"+strconv.Itoa(int(createdProduct.ID)), bytes.NewBufferString(`{"Category": "odzież"}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", etag(createdProduct))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var patchedProduct models.Product
+	json.Unmarshal(rr.Body.Bytes(), &patchedProduct)
+	assert.Equal(t, "Odzież", patchedProduct.Category)
+}
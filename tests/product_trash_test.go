@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func deleteTestProduct(t *testing.T, router http.Handler, product models.Product) {
+	req, _ := http.NewRequest("DELETE", "/products/"+strconv.Itoa(int(product.ID)), nil)
+	req.Header.Set("If-Match", etag(product))
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+}
+
+func TestDeletedProductInTrashAndRestore(t *testing.T) {
+	router := setupRouter()
+
+	product := models.Product{
+		Name:        "TrashProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	}
+	createdProduct := createTestProduct(t, router, product)
+	deleteTestProduct(t, router, createdProduct)
+
+	reqTrash, _ := http.NewRequest("GET", "/products/trash", nil)
+	rrTrash := httptest.NewRecorder()
+	router.ServeHTTP(rrTrash, reqTrash)
+	assert.Equal(t, http.StatusOK, rrTrash.Code)
+
+	var trash []models.Product
+	json.Unmarshal(rrTrash.Body.Bytes(), &trash)
+	assert.Len(t, trash, 1)
+
+	// Nazwa produktu w koszu jest nadal zajęta
+	rrDuplicate := postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusBadRequest, rrDuplicate.Code)
+	assert.Contains(t, rrDuplicate.Body.String(), "w koszu")
+
+	reqRestore, _ := http.NewRequest("POST", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/restore", nil)
+	rrRestore := httptest.NewRecorder()
+	router.ServeHTTP(rrRestore, reqRestore)
+	assert.Equal(t, http.StatusOK, rrRestore.Code)
+
+	reqGet, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID)), nil)
+	rrGet := httptest.NewRecorder()
+	router.ServeHTTP(rrGet, reqGet)
+	assert.Equal(t, http.StatusOK, rrGet.Code)
+
+	reqHistory, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/history", nil)
+	rrHistory := httptest.NewRecorder()
+	router.ServeHTTP(rrHistory, reqHistory)
+
+	var history []models.ProductHistory
+	json.Unmarshal(rrHistory.Body.Bytes(), &history)
+
+	var deletions int
+	for _, h := range history {
+		if h.Field == "Deleted" {
+			deletions++
+		}
+	}
+	assert.Equal(t, 2, deletions)
+}
+
+func TestPurgeProduct(t *testing.T) {
+	router := setupRouter()
+
+	product := models.Product{
+		Name:        "PurgeProduct",
+		Category:    "Elektronika",
+		Description: "Opis produktu",
+		Price:       500.0,
+		Quantity:    1,
+	}
+	createdProduct := createTestProduct(t, router, product)
+
+	// Produktu spoza kosza nie można usunąć trwale
+	reqPurge, _ := http.NewRequest("DELETE", "/products/"+strconv.Itoa(int(createdProduct.ID))+"/purge", nil)
+	rrPurge := httptest.NewRecorder()
+	router.ServeHTTP(rrPurge, reqPurge)
+	assert.Equal(t, http.StatusNotFound, rrPurge.Code)
+
+	deleteTestProduct(t, router, createdProduct)
+
+	rrPurge = httptest.NewRecorder()
+	router.ServeHTTP(rrPurge, reqPurge)
+	assert.Equal(t, http.StatusNoContent, rrPurge.Code)
+
+	// Po trwałym usunięciu nazwa jest znowu wolna
+	rrCreate := postJSON(router, "/products", product)
+	assert.Equal(t, http.StatusCreated, rrCreate.Code)
+}
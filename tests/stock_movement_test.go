@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postStockMovement(router http.Handler, productID uint, movement map[string]interface{}) *httptest.ResponseRecorder {
+	return postJSON(router, "/products/"+strconv.Itoa(int(productID))+"/stock-movements", movement)
+}
+
+func getStockMovements(router http.Handler, productID uint) []models.StockMovement {
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(productID))+"/stock-movements", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var movements []models.StockMovement
+	json.Unmarshal(rr.Body.Bytes(), &movements)
+	return movements
+}
+
+func getTestProduct(router http.Handler, productID uint) models.Product {
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(productID)), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var product models.Product
+	json.Unmarshal(rr.Body.Bytes(), &product)
+	return product
+}
+
+func TestPostStockMovements(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, blacklistTestProduct("StockProduct"))
+
+	rr := postStockMovement(router, product.ID, map[string]interface{}{"Type": "receipt", "Quantity": 10, "Reference": "PZ/1/2026"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	var movement models.StockMovement
+	json.Unmarshal(rr.Body.Bytes(), &movement)
+	assert.Equal(t, 11, movement.BalanceAfter)
+	assert.Equal(t, "PZ/1/2026", movement.Reference)
+
+	rr = postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -4, "Reason": "zamówienie 17"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	updated := getTestProduct(router, product.ID)
+	assert.Equal(t, 7, updated.Quantity)
+	assert.Equal(t, uint(3), updated.Version)
+
+	// Rejestr zawiera też korektę z utworzenia produktu, a suma ruchów równa się stanowi
+	movements := getStockMovements(router, product.ID)
+	if assert.Len(t, movements, 3) {
+		assert.Equal(t, models.MovementSale, movements[0].Type)
+		assert.Equal(t, "zamówienie 17", movements[0].Reason)
+		assert.Equal(t, models.MovementAdjustment, movements[2].Type)
+	}
+	sum := 0
+	for _, m := range movements {
+		sum += m.Quantity
+	}
+	assert.Equal(t, updated.Quantity, sum)
+
+	history := getTestProductHistory(router, product.ID)
+	last := history[len(history)-1]
+	assert.Equal(t, "Quantity", last.Field)
+	assert.Equal(t, "11", last.OldValue)
+	assert.Equal(t, "7", last.NewValue)
+}
+
+func TestPostStockMovementValidation(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, blacklistTestProduct("StockLimits"))
+
+	// Sprzedaż większa niż stan
+	rr := postStockMovement(router, product.ID, map[string]interface{}{"Type": "sale", "Quantity": -2})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "ilość produktów nie może być ujemna")
+
+	// Zły znak dla rodzaju ruchu
+	rr = postStockMovement(router, product.ID, map[string]interface{}{"Type": "receipt", "Quantity": -1})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = postStockMovement(router, product.ID, map[string]interface{}{"Type": "theft", "Quantity": -1})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = postStockMovement(router, 99999, map[string]interface{}{"Type": "receipt", "Quantity": 1})
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	unchanged := getTestProduct(router, product.ID)
+	assert.Equal(t, 1, unchanged.Quantity)
+	assert.Equal(t, product.Version, unchanged.Version)
+	assert.Len(t, getStockMovements(router, product.ID), 1)
+
+	// Nieaktualny ETag
+	body, _ := json.Marshal(map[string]interface{}{"Type": "write_off", "Quantity": -1})
+	req, _ := http.NewRequest("POST", "/products/"+strconv.Itoa(int(product.ID))+"/stock-movements", bytes.NewBuffer(body))
+	req.Header.Set("If-Match", `"7"`)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+}
+
+func TestUpdateQuantityRecordsAdjustment(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, blacklistTestProduct("StockEdit"))
+
+	product.Quantity = 6
+	body, _ := json.Marshal(product)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(product.ID)), bytes.NewBuffer(body))
+	req.Header.Set("If-Match", etag(product))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	movements := getStockMovements(router, product.ID)
+	if assert.Len(t, movements, 2) {
+		assert.Equal(t, models.MovementAdjustment, movements[0].Type)
+		assert.Equal(t, 5, movements[0].Quantity)
+		assert.Equal(t, 6, movements[0].BalanceAfter)
+	}
+}
@@ -0,0 +1,175 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"product-controller/repository"
+	"product-controller/service"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func reserveTestStock(router http.Handler, productID uint, request map[string]interface{}) (*httptest.ResponseRecorder, models.StockReservation) {
+	rr := postJSON(router, "/products/"+strconv.Itoa(int(productID))+"/reservations", request)
+
+	var reservation models.StockReservation
+	json.Unmarshal(rr.Body.Bytes(), &reservation)
+	return rr, reservation
+}
+
+func finishTestReservation(router http.Handler, reservation models.StockReservation, action string) *httptest.ResponseRecorder {
+	path := "/products/" + strconv.Itoa(int(reservation.ProductID)) + "/reservations/" + strconv.Itoa(int(reservation.ID)) + "/" + action
+	req, _ := http.NewRequest("POST", path, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func getTestProductJSON(router http.Handler, productID uint) map[string]interface{} {
+	req, _ := http.NewRequest("GET", "/products/"+strconv.Itoa(int(productID)), nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var product map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &product)
+	return product
+}
+
+func TestReserveConfirmAndReleaseStock(t *testing.T) {
+	router := setupRouter()
+
+	product := blacklistTestProduct("ReservedProduct")
+	product.Quantity = 5
+	product = createTestProduct(t, router, product)
+
+	rr, first := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 3, "Reference": "koszyk-1"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, models.ReservationActive, first.Status)
+	assert.WithinDuration(t, time.Now().Add(service.DefaultReservationTTL), first.ExpiresAt, time.Minute)
+
+	current := getTestProductJSON(router, product.ID)
+	assert.Equal(t, float64(5), current["Quantity"])
+	assert.Equal(t, float64(3), current["Reserved"])
+	assert.Equal(t, float64(2), current["Available"])
+
+	// Dostępne są już tylko 2 sztuki
+	rr, _ = reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 3})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	_, second := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 2, "TTLSeconds": 60})
+
+	rr = finishTestReservation(router, first, "confirm")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, `"2"`, rr.Header().Get("ETag"))
+
+	rr = finishTestReservation(router, first, "confirm")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	// Zwolnienie zapisuje, kto i dlaczego zamknął rezerwację
+	req, _ := http.NewRequest("POST", "/products/"+strconv.Itoa(int(product.ID))+"/reservations/"+strconv.Itoa(int(second.ID))+"/release", nil)
+	req.Header.Set("X-Actor", "kasjer")
+	req.Header.Set("X-Change-Reason", "klient zrezygnował")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var released models.StockReservation
+	json.Unmarshal(rr.Body.Bytes(), &released)
+	assert.Equal(t, models.ReservationReleased, released.Status)
+	assert.Equal(t, "kasjer", released.Closed.Actor)
+	assert.Equal(t, "klient zrezygnował", released.Closed.Reason)
+
+	current = getTestProductJSON(router, product.ID)
+	assert.Equal(t, float64(2), current["Quantity"])
+	assert.Equal(t, float64(0), current["Reserved"])
+	assert.Equal(t, float64(2), current["Available"])
+
+	movements := getStockMovements(router, product.ID)
+	if assert.Len(t, movements, 2) {
+		assert.Equal(t, models.MovementSale, movements[0].Type)
+		assert.Equal(t, -3, movements[0].Quantity)
+		assert.Equal(t, "koszyk-1", movements[0].Reference)
+	}
+}
+
+func TestConcurrentReservationsDoNotOversell(t *testing.T) {
+	router := setupRouter()
+
+	product := blacklistTestProduct("ConcurrentStock")
+	product.Quantity = 5
+	product = createTestProduct(t, router, product)
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr, _ := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 1})
+			codes <- rr.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	created := 0
+	for code := range codes {
+		if code == http.StatusCreated {
+			created++
+		} else {
+			assert.Equal(t, http.StatusConflict, code)
+		}
+	}
+	assert.Equal(t, 5, created)
+
+	current := getTestProductJSON(router, product.ID)
+	assert.Equal(t, float64(5), current["Reserved"])
+	assert.Equal(t, float64(0), current["Available"])
+}
+
+func TestExpiredReservationsAreReleased(t *testing.T) {
+	router := setupRouter()
+
+	product := createTestProduct(t, router, blacklistTestProduct("ExpiringStock"))
+	_, reservation := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 1, "TTLSeconds": 60})
+
+	productService := service.NewProductService(repository.NewProductRepository(), nil, nil, repository.NewWarehouseRepository())
+	expired, err := productService.ExpireReservations(time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+
+	current := getTestProductJSON(router, product.ID)
+	assert.Equal(t, float64(0), current["Reserved"])
+
+	rr := finishTestReservation(router, reservation, "confirm")
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestUpdateQuantityBelowReserved(t *testing.T) {
+	router := setupRouter()
+
+	product := blacklistTestProduct("ReservedEdit")
+	product.Quantity = 4
+	product = createTestProduct(t, router, product)
+	reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 3})
+
+	product.Quantity = 2
+	body, _ := json.Marshal(product)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(product.ID)), bytes.NewBuffer(body))
+	req.Header.Set("If-Match", etag(product))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "ilość zarezerwowana")
+
+	rr = postStockMovement(router, product.ID, map[string]interface{}{"Type": "write_off", "Quantity": -2})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Contains(t, rr.Body.String(), "ilość zarezerwowana")
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"product-controller/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addTestWarehouse(t *testing.T, router http.Handler, code string) models.Warehouse {
+	rr := postJSON(router, "/warehouses", models.Warehouse{Code: code, Name: "Magazyn " + code})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	var warehouse models.Warehouse
+	json.Unmarshal(rr.Body.Bytes(), &warehouse)
+	return warehouse
+}
+
+// getTestStockLevels - Ilość w poszczególnych magazynach produktu, po kodzie magazynu
+func getTestStockLevels(router http.Handler, path string) map[string]int {
+	req, _ := http.NewRequest("GET", path, nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var levels []models.StockLevel
+	json.Unmarshal(rr.Body.Bytes(), &levels)
+
+	quantities := make(map[string]int)
+	for _, level := range levels {
+		quantities[level.Warehouse.Code] = level.Quantity
+	}
+	return quantities
+}
+
+func TestAddWarehouse(t *testing.T) {
+	router := setupRouter()
+
+	addTestWarehouse(t, router, "west")
+
+	rr := postJSON(router, "/warehouses", models.Warehouse{Code: "WEST", Name: "Drugi zachodni"})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = postJSON(router, "/warehouses", models.Warehouse{Code: "Z", Name: "Za krótki kod"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ := http.NewRequest("GET", "/warehouses", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	var warehouses []models.Warehouse
+	json.Unmarshal(rr.Body.Bytes(), &warehouses)
+	if assert.Len(t, warehouses, 2) {
+		assert.Equal(t, models.DefaultWarehouseCode, warehouses[0].Code)
+		assert.Equal(t, "WEST", warehouses[1].Code)
+	}
+}
+
+func TestStockLevelsAndTransfers(t *testing.T) {
+	router := setupRouter()
+
+	west := addTestWarehouse(t, router, "WEST")
+	product := blacklistTestProduct("WarehouseProduct")
+	product.Quantity = 10
+	product = createTestProduct(t, router, product)
+	stockPath := "/products/" + strconv.Itoa(int(product.ID)) + "/stock"
+
+	assert.Equal(t, map[string]int{"MAIN": 10}, getTestStockLevels(router, stockPath))
+
+	rr := postStockMovement(router, product.ID, map[string]interface{}{"Type": "receipt", "Quantity": 5, "Warehouse": "WEST"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, map[string]int{"MAIN": 10, "WEST": 5}, getTestStockLevels(router, stockPath))
+
+	// Przy stanie w kilku magazynach ilości nie można już zmienić bezpośrednio
+	current := getTestProduct(router, product.ID)
+	current.Quantity = 12
+	body, _ := json.Marshal(current)
+	req, _ := http.NewRequest("PUT", "/products/"+strconv.Itoa(int(product.ID)), bytes.NewBuffer(body))
+	req.Header.Set("If-Match", etag(current))
+	rrPut := httptest.NewRecorder()
+	router.ServeHTTP(rrPut, req)
+	assert.Equal(t, http.StatusConflict, rrPut.Code)
+
+	transferPath := "/products/" + strconv.Itoa(int(product.ID)) + "/transfers"
+	rr = postJSON(router, transferPath, map[string]interface{}{"From": "MAIN", "To": "WEST", "Quantity": 4, "Reference": "MM/1"})
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]int{"MAIN": 6, "WEST": 9}, getTestStockLevels(router, stockPath))
+
+	// Łączny stan produktu się nie zmienia
+	assert.Equal(t, 15, getTestProduct(router, product.ID).Quantity)
+
+	rr = postJSON(router, transferPath, map[string]interface{}{"From": "WEST", "To": "MAIN", "Quantity": 20})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr = postJSON(router, transferPath, map[string]interface{}{"From": "WEST", "To": "EAST", "Quantity": 1})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.Equal(t, map[string]int{"MAIN": 6, "WEST": 9}, getTestStockLevels(router, stockPath))
+	assert.Equal(t, map[string]int{"WEST": 9}, getTestStockLevels(router, "/warehouses/"+strconv.Itoa(int(west.ID))+"/stock"))
+
+	movements := getStockMovements(router, product.ID)
+	if assert.Len(t, movements, 4) {
+		assert.Equal(t, models.MovementTransfer, movements[0].Type)
+		assert.Equal(t, west.ID, movements[0].WarehouseID)
+		assert.Equal(t, 4, movements[0].Quantity)
+		assert.Equal(t, -4, movements[1].Quantity)
+	}
+}
+
+func TestReservationLimitedToWarehouseStock(t *testing.T) {
+	router := setupRouter()
+
+	addTestWarehouse(t, router, "WEST")
+	product := blacklistTestProduct("WarehouseHold")
+	product.Quantity = 5
+	product = createTestProduct(t, router, product)
+	postStockMovement(router, product.ID, map[string]interface{}{"Type": "receipt", "Quantity": 2, "Warehouse": "WEST"})
+
+	// Łącznie dostępnych jest 7 sztuk, ale w magazynie WEST tylko 2
+	rr, _ := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 3, "Warehouse": "WEST"})
+	assert.Equal(t, http.StatusConflict, rr.Code)
+
+	rr, reservation := reserveTestStock(router, product.ID, map[string]interface{}{"Quantity": 2, "Warehouse": "WEST"})
+	assert.Equal(t, http.StatusCreated, rr.Code)
+
+	rr = finishTestReservation(router, reservation, "confirm")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]int{"MAIN": 5, "WEST": 0}, getTestStockLevels(router, "/products/"+strconv.Itoa(int(product.ID))+"/stock"))
+}